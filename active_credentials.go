@@ -0,0 +1,194 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const activeStoragePrefix = "active/"
+
+var errMaxActive = errors.New("max_active reached")
+
+// activeCredentialEntry tracks an unexpired lease counted towards a role's max_active. Every lease
+// of a role that can set max_active is tracked, so that setting it later counts the leases issued
+// before. Batch leases count once per credential they cover.
+type activeCredentialEntry struct {
+	Count     int       `json:"count"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// reserveActiveCredentials records count new credentials for a role, failing with errMaxActive if that
+// would exceed maxActive. A maxActive of 0 records them without a limit. It returns the id to store in
+// the lease's internal data so revocation can release the reservation.
+func (b *cloudflareBackend) reserveActiveCredentials(ctx context.Context, s logical.Storage, roleName string, maxActive int, count int, ttl time.Duration) (string, error) {
+	b.activeLock.Lock()
+	defer b.activeLock.Unlock()
+
+	prefix := activeStoragePrefix + roleName + "/"
+	now := time.Now()
+
+	if maxActive > 0 {
+		active, oldest, err := countActiveCredentials(ctx, s, prefix, now)
+		if err != nil {
+			return "", err
+		}
+
+		if active+count > maxActive {
+			return "", fmt.Errorf("%w: role %q has %d active credentials of a maximum of %d, the oldest expires at %s",
+				errMaxActive, roleName, active, maxActive, oldest.UTC().Format(time.RFC3339))
+		}
+	}
+
+	id := uuid.New().String()
+	if err := setActiveCredential(ctx, s, prefix+id, &activeCredentialEntry{
+		Count:     count,
+		ExpiresAt: now.Add(b.leaseTTL(ttl)),
+	}); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// countActiveCredentials returns how many credentials the reservations under prefix cover, and when
+// the oldest of them expires. Reservations whose expiry has passed are not counted and are cleaned up.
+// Callers must hold activeLock.
+func countActiveCredentials(ctx context.Context, s logical.Storage, prefix string, now time.Time) (int, time.Time, error) {
+	ids, err := s.List(ctx, prefix)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	active := 0
+	var oldest time.Time
+
+	for _, id := range ids {
+		entry, err := getActiveCredential(ctx, s, prefix+id)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+
+		if entry == nil {
+			continue
+		}
+
+		if !entry.ExpiresAt.After(now) {
+			if err := s.Delete(ctx, prefix+id); err != nil {
+				return 0, time.Time{}, err
+			}
+			continue
+		}
+
+		active += entry.Count
+		if oldest.IsZero() || entry.ExpiresAt.Before(oldest) {
+			oldest = entry.ExpiresAt
+		}
+	}
+
+	return active, oldest, nil
+}
+
+// releaseActiveCredentials removes count credentials from a reservation, for a revoked lease, credentials
+// that failed to be issued or a token deleted for being idle. The reservation is removed once it covers
+// no credentials.
+func (b *cloudflareBackend) releaseActiveCredentials(ctx context.Context, s logical.Storage, roleName string, activeId string, count int) error {
+	key := activeStoragePrefix + roleName + "/" + activeId
+
+	b.activeLock.Lock()
+	defer b.activeLock.Unlock()
+
+	entry, err := getActiveCredential(ctx, s, key)
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		return nil
+	}
+
+	entry.Count -= count
+	if entry.Count <= 0 {
+		return s.Delete(ctx, key)
+	}
+	return setActiveCredential(ctx, s, key, entry)
+}
+
+// renewActiveCredentials moves the expiry of the reservation of a renewed lease.
+func (b *cloudflareBackend) renewActiveCredentials(ctx context.Context, s logical.Storage, roleName string, activeId string, secret *logical.Secret) error {
+	key := activeStoragePrefix + roleName + "/" + activeId
+
+	ttl, _, err := framework.CalculateTTL(b.System(), secret.Increment, 0, 0, secret.MaxTTL, 0, secret.IssueTime)
+	if err != nil {
+		return err
+	}
+
+	b.activeLock.Lock()
+	defer b.activeLock.Unlock()
+
+	entry, err := getActiveCredential(ctx, s, key)
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		return nil
+	}
+
+	entry.ExpiresAt = time.Now().Add(ttl)
+	return setActiveCredential(ctx, s, key, entry)
+}
+
+// leaseTTL returns the TTL a lease issued with ttl receives, falling back to the mount default.
+func (b *cloudflareBackend) leaseTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 {
+		return ttl
+	}
+	return b.System().DefaultLeaseTTL()
+}
+
+// leaseActiveCredentials returns the role and reservation id of a lease counted towards max_active.
+func leaseActiveCredentials(secret *logical.Secret) (string, string, bool) {
+	roleRaw, ok := secret.InternalData["role"]
+	if !ok {
+		return "", "", false
+	}
+
+	activeIdRaw, ok := secret.InternalData["active_id"]
+	if !ok {
+		return "", "", false
+	}
+
+	return roleRaw.(string), activeIdRaw.(string), true
+}
+
+func getActiveCredential(ctx context.Context, s logical.Storage, key string) (*activeCredentialEntry, error) {
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	var active activeCredentialEntry
+	if err := entry.DecodeJSON(&active); err != nil {
+		return nil, err
+	}
+	return &active, nil
+}
+
+func setActiveCredential(ctx context.Context, s logical.Storage, key string, active *activeCredentialEntry) error {
+	entry, err := logical.StorageEntryJSON(key, active)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
@@ -0,0 +1,64 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveActiveCredentials(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+
+	first, err := b.reserveActiveCredentials(ctx, s, "ci", 3, 2, time.Hour)
+	require.NoError(t, err)
+
+	_, err = b.reserveActiveCredentials(ctx, s, "ci", 3, 2, time.Hour)
+	require.True(t, errors.Is(err, errMaxActive))
+	require.Contains(t, err.Error(), "has 2 active credentials of a maximum of 3")
+
+	_, err = b.reserveActiveCredentials(ctx, s, "other", 3, 2, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, b.releaseActiveCredentials(ctx, s, "ci", first, 2))
+
+	_, err = b.reserveActiveCredentials(ctx, s, "ci", 3, 3, time.Hour)
+	require.NoError(t, err)
+}
+
+func TestReserveActiveCredentialsExpired(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+
+	id, err := b.reserveActiveCredentials(ctx, s, "ci", 1, 1, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, setActiveCredential(ctx, s, activeStoragePrefix+"ci/"+id, &activeCredentialEntry{
+		Count:     1,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+
+	_, err = b.reserveActiveCredentials(ctx, s, "ci", 1, 1, time.Hour)
+	require.NoError(t, err)
+
+	ids, err := s.List(ctx, activeStoragePrefix+"ci/")
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+}
+
+func TestReserveActiveCredentialsUncapped(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+
+	// Leases issued before max_active was set still count once it is.
+	for i := 0; i < 2; i++ {
+		_, err := b.reserveActiveCredentials(ctx, s, "ci", 0, 1, time.Hour)
+		require.NoError(t, err)
+	}
+
+	_, err := b.reserveActiveCredentials(ctx, s, "ci", 2, 1, time.Hour)
+	require.True(t, errors.Is(err, errMaxActive))
+}
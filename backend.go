@@ -2,15 +2,17 @@ package cloudflare_secrets_engine
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
-	b := backend()
+	b := backend(newClient)
 	if err := b.Setup(ctx, conf); err != nil {
 		return nil, err
 	}
@@ -19,12 +21,33 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 
 type cloudflareBackend struct {
 	*framework.Backend
-	lock   sync.RWMutex
-	client *cloudflareClient
+	lock      sync.RWMutex
+	client    cloudflareClient
+	newClient clientFactory
+
+	accessPolicyLock sync.Mutex
+	staticRoleLock   sync.Mutex
+	workerSecretLock sync.Mutex
+	activeLock       sync.Mutex
+	rateLimitLock    sync.Mutex
+	activeCountLock  sync.Mutex
+
+	issuanceLimiters map[string]*issuanceLimiter
+
+	// activeCounts holds the number of credentials covered by unrevoked leases, by role.
+	activeCounts map[string]int
+
+	// lastIdleCheck is only used by periodicFunc, which Vault does not run concurrently.
+	lastIdleCheck time.Time
 }
 
-func backend() *cloudflareBackend {
-	var b = cloudflareBackend{}
+// backend creates the engine, talking to Cloudflare through the clients built by newClient.
+func backend(newClient clientFactory) *cloudflareBackend {
+	var b = cloudflareBackend{
+		newClient:        newClient,
+		issuanceLimiters: make(map[string]*issuanceLimiter),
+		activeCounts:     make(map[string]int),
+	}
 
 	b.Backend = &framework.Backend{
 		Help: strings.TrimSpace(backendHelp),
@@ -33,20 +56,46 @@ func backend() *cloudflareBackend {
 			SealWrapStorage: []string{
 				"config",
 				"service-token/*",
+				staticRoleStoragePrefix,
+				signingKeyStoragePrefix,
+				workerSecretStoragePrefix,
+				orphanStoragePrefix,
 			},
 		},
 		Paths: framework.PathAppend(
 			pathRole(&b),
+			pathStaticRole(&b),
+			pathSigningKey(&b),
 			[]*framework.Path{
 				pathConfig(&b),
 				pathServiceTokens(&b),
+				pathAPIToken(&b),
+				pathOriginCA(&b),
+				pathClientCert(&b),
+				pathDNSRecord(&b),
+				pathJITAccess(&b),
+				pathMember(&b),
+				pathFirewallAllow(&b),
+				pathStaticCreds(&b),
+				pathSign(&b),
+				pathCredsActivity(&b),
 			},
 		),
 		Secrets: []*framework.Secret{
-			b.cloudflareServiceToken(),
+			b.instrumentSecret(credentialTypeService, b.cloudflareServiceToken()),
+			b.instrumentSecret(credentialTypeService, b.cloudflareServiceTokenBatch()),
+			b.instrumentSecret(credentialTypeAPI, b.cloudflareAPIToken()),
+			b.instrumentSecret(credentialTypeOriginCA, b.cloudflareOriginCACertificate()),
+			b.instrumentSecret(credentialTypeClientCert, b.cloudflareClientCertificate()),
+			b.instrumentSecret(credentialTypeDNSRecord, b.cloudflareDNSRecord()),
+			b.instrumentSecret(credentialTypeJITAccess, b.cloudflareAccessGrant()),
+			b.instrumentSecret(credentialTypeMember, b.cloudflareAccountMember()),
+			b.instrumentSecret(credentialTypeFirewall, b.cloudflareFirewallAllow()),
 		},
-		BackendType: logical.TypeLogical,
-		Invalidate:  b.invalidate,
+		BackendType:    logical.TypeLogical,
+		Invalidate:     b.invalidate,
+		InitializeFunc: b.initialize,
+		PeriodicFunc:   b.periodicFunc,
 	}
 	return &b
 }
@@ -57,13 +106,39 @@ func (b *cloudflareBackend) reset() {
 	b.client = nil
 }
 
+// initialize seeds the active credentials gauge. Gauges are best effort, so failing to seed it is
+// only logged.
+func (b *cloudflareBackend) initialize(ctx context.Context, req *logical.InitializationRequest) error {
+	if err := b.seedActiveCredentials(ctx, req.Storage); err != nil {
+		b.Logger().Error("error counting active credentials", "error", err)
+	}
+	return nil
+}
+
 func (b *cloudflareBackend) invalidate(ctx context.Context, key string) {
-	if key == "config" {
+	switch {
+	case key == "config":
 		b.reset()
+	case strings.HasPrefix(key, "role/"):
+		b.forgetIssuanceLimiter(strings.TrimPrefix(key, "role/"))
 	}
 }
 
-func (b *cloudflareBackend) getClient(ctx context.Context, s logical.Storage) (*cloudflareClient, error) {
+func (b *cloudflareBackend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	rotateErr := b.rotateExpiredStaticRoles(ctx, req)
+
+	orphaned, orphanErr := b.countOrphanedTokens(ctx, req.Storage)
+
+	var idleErr error
+	if time.Since(b.lastIdleCheck) >= idleCheckInterval {
+		b.lastIdleCheck = time.Now()
+		idleErr = b.revokeIdleCredentials(ctx, req.Storage)
+	}
+
+	return errors.Join(rotateErr, orphanErr, idleErr, b.emitCredentialGauges(ctx, req.Storage, orphaned))
+}
+
+func (b *cloudflareBackend) getClient(ctx context.Context, s logical.Storage) (cloudflareClient, error) {
 	b.lock.RLock()
 	unlockFunc := b.lock.RUnlock
 	defer func() { unlockFunc() }()
@@ -85,7 +160,7 @@ func (b *cloudflareBackend) getClient(ctx context.Context, s logical.Storage) (*
 		config = new(cloudflareConfig)
 	}
 
-	b.client, err = newClient(config)
+	b.client, err = b.newClient(config)
 	if err != nil {
 		return nil, err
 	}
@@ -94,5 +169,9 @@ func (b *cloudflareBackend) getClient(ctx context.Context, s logical.Storage) (*
 }
 
 const backendHelp = `
-The Cloudflare secrets backend dynamically generates Cloudflare API tokens and Access service tokens.
+The Cloudflare secrets backend dynamically generates Cloudflare API tokens, Access service tokens,
+Origin CA certificates, API Shield client certificates, lease-bound DNS records,
+just-in-time Access policy grants, temporary account memberships and IP allowlist entries.
+Static roles rotate existing Cloudflare credentials, such as Turnstile widget secrets, on a schedule.
+Signing keys sign Stream and Images URLs on request without releasing the key itself.
 `
@@ -32,6 +32,25 @@ func getTestBackend(tb testing.TB) (*cloudflareBackend, logical.Storage) {
 	return b.(*cloudflareBackend), config.StorageView
 }
 
+// getTestBackendWithClient returns a backend that talks to client instead of Cloudflare.
+func getTestBackendWithClient(tb testing.TB, client cloudflareClient) (*cloudflareBackend, logical.Storage) {
+	tb.Helper()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = new(logical.InmemStorage)
+	config.Logger = hclog.NewNullLogger()
+	config.System = logical.TestSystemView()
+
+	b := backend(func(*cloudflareConfig) (cloudflareClient, error) {
+		return client, nil
+	})
+	if err := b.Setup(context.Background(), config); err != nil {
+		tb.Fatal(err)
+	}
+
+	return b, config.StorageView
+}
+
 var runAcceptanceTests = os.Getenv(envVarRunAcceptanceTests) == "1"
 
 type testEnv struct {
@@ -0,0 +1,49 @@
+package cloudflare_secrets_engine
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const nameSuffixMaxLength = 32
+
+var nameSuffixRegex = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// callerTTL returns the lease TTL requested by the caller, or zero to use the default. Requests
+// longer than the role's max_ttl, or the mount's max lease TTL if the role has none, are rejected.
+func callerTTL(requested time.Duration, roleMaxTTL time.Duration, sys logical.SystemView) (time.Duration, error) {
+	if requested < 0 {
+		return 0, fmt.Errorf("ttl must not be negative")
+	}
+
+	ceiling := sys.MaxLeaseTTL()
+	if roleMaxTTL > 0 && roleMaxTTL < ceiling {
+		ceiling = roleMaxTTL
+	}
+
+	if requested > ceiling {
+		return 0, fmt.Errorf("ttl %s exceeds the maximum of %s", requested, ceiling)
+	}
+
+	return requested, nil
+}
+
+// validateNameSuffix checks a caller supplied credential name suffix.
+func validateNameSuffix(suffix string) error {
+	if suffix == "" {
+		return nil
+	}
+
+	if len(suffix) > nameSuffixMaxLength {
+		return fmt.Errorf("name_suffix must be at most %d characters", nameSuffixMaxLength)
+	}
+
+	if !nameSuffixRegex.MatchString(suffix) {
+		return fmt.Errorf("name_suffix may only contain letters, digits, '.', '_' and '-'")
+	}
+
+	return nil
+}
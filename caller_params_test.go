@@ -0,0 +1,34 @@
+package cloudflare_secrets_engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerTTL(t *testing.T) {
+	sys := &logical.StaticSystemView{MaxLeaseTTLVal: 24 * time.Hour}
+
+	ttl, err := callerTTL(0, time.Hour, sys)
+	require.NoError(t, err)
+	require.Zero(t, ttl)
+
+	ttl, err = callerTTL(30*time.Minute, time.Hour, sys)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, ttl)
+
+	_, err = callerTTL(2*time.Hour, time.Hour, sys)
+	require.Error(t, err)
+
+	_, err = callerTTL(48*time.Hour, 0, sys)
+	require.Error(t, err)
+}
+
+func TestValidateNameSuffix(t *testing.T) {
+	require.NoError(t, validateNameSuffix(""))
+	require.NoError(t, validateNameSuffix("pipeline-42.main"))
+	require.Error(t, validateNameSuffix("pipeline 42"))
+	require.Error(t, validateNameSuffix("pipeline-42-0123456789abcdefghijklmnop"))
+}
@@ -0,0 +1,110 @@
+package cloudflare_secrets_engine
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+)
+
+const (
+	keyTypeRSA   = "rsa"
+	keyTypeECDSA = "ecdsa"
+
+	rsaKeyBits = 2048
+)
+
+type certificateKeyPair struct {
+	CSR            string
+	PrivateKey     string
+	PrivateKeyType string
+}
+
+func validKeyType(keyType string) bool {
+	return keyType == keyTypeRSA || keyType == keyTypeECDSA
+}
+
+func generateKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case keyTypeRSA:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case keyTypeECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", keyType)
+	}
+}
+
+func generateKeyAndCSR(keyType string, commonName string, hostnames []string) (*certificateKeyPair, error) {
+	key, err := generateKey(keyType)
+	if err != nil {
+		return nil, fmt.Errorf("error generating private key: %w", err)
+	}
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: hostnames,
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating certificate signing request: %w", err)
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling private key: %w", err)
+	}
+
+	return &certificateKeyPair{
+		CSR:            string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+		PrivateKey:     string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		PrivateKeyType: keyType,
+	}, nil
+}
+
+func parseCSR(csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(csrPEM)))
+	if block == nil {
+		return nil, errors.New("csr is not PEM encoded")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing csr: %w", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid csr signature: %w", err)
+	}
+
+	return csr, nil
+}
+
+func csrHostnames(csr *x509.CertificateRequest) []string {
+	hostnames := csr.DNSNames
+	if csr.Subject.CommonName != "" && !strutil.StrListContains(hostnames, csr.Subject.CommonName) {
+		hostnames = append([]string{csr.Subject.CommonName}, hostnames...)
+	}
+	return hostnames
+}
+
+func csrKeyType(csr *x509.CertificateRequest) string {
+	switch csr.PublicKeyAlgorithm {
+	case x509.RSA:
+		return keyTypeRSA
+	case x509.ECDSA:
+		return keyTypeECDSA
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,25 @@
+package cloudflare_secrets_engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKeyAndCSR(t *testing.T) {
+	for _, keyType := range []string{keyTypeRSA, keyTypeECDSA} {
+		t.Run(keyType, func(t *testing.T) {
+			keyPair, err := generateKeyAndCSR(keyType, "example.com", []string{"example.com", "www.example.com"})
+			require.NoError(t, err)
+			require.Contains(t, keyPair.PrivateKey, "PRIVATE KEY")
+
+			csr, err := parseCSR(keyPair.CSR)
+			require.NoError(t, err)
+			require.Equal(t, keyType, csrKeyType(csr))
+			require.Equal(t, []string{"example.com", "www.example.com"}, csrHostnames(csr))
+		})
+	}
+
+	_, err := parseCSR("not a csr")
+	require.Error(t, err)
+}
@@ -1,15 +1,65 @@
 package cloudflare_secrets_engine
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+
 	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/go-cleanhttp"
 )
 
-type cloudflareClient struct {
-	*cloudflare.API
+// cloudflareClient is the subset of the Cloudflare API the engine uses. It is implemented by
+// *cloudflare.API, and can be replaced with a fake in tests or an alternate backend.
+type cloudflareClient interface {
+	// Raw calls endpoints cloudflare-go does not wrap, such as account-owned API tokens,
+	// Stream and Images signing keys and Turnstile widgets.
+	Raw(ctx context.Context, method, endpoint string, data interface{}, headers http.Header) (json.RawMessage, error)
+
+	CreateAccessServiceToken(ctx context.Context, accountID, name string) (cloudflare.AccessServiceTokenCreateResponse, error)
+	RefreshAccessServiceToken(ctx context.Context, rc *cloudflare.ResourceContainer, id string) (cloudflare.AccessServiceTokenRefreshResponse, error)
+	DeleteAccessServiceToken(ctx context.Context, accountID, uuid string) (cloudflare.AccessServiceTokenUpdateResponse, error)
+
+	CreateAPIToken(ctx context.Context, token cloudflare.APIToken) (cloudflare.APIToken, error)
+	GetAPIToken(ctx context.Context, tokenID string) (cloudflare.APIToken, error)
+	UpdateAPIToken(ctx context.Context, tokenID string, token cloudflare.APIToken) (cloudflare.APIToken, error)
+	DeleteAPIToken(ctx context.Context, tokenID string) error
+
+	AccessPolicy(ctx context.Context, accountID, applicationID, policyID string) (cloudflare.AccessPolicy, error)
+	UpdateAccessPolicy(ctx context.Context, accountID, applicationID string, accessPolicy cloudflare.AccessPolicy) (cloudflare.AccessPolicy, error)
+
+	CreateAccountMember(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.CreateAccountMemberParams) (cloudflare.AccountMember, error)
+	DeleteAccountMember(ctx context.Context, accountID string, userID string) error
+
+	CreateDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.CreateDNSRecordParams) (cloudflare.DNSRecord, error)
+	DeleteDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, recordID string) error
+
+	CreateListItem(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.ListCreateItemParams) ([]cloudflare.ListItem, error)
+	DeleteListItems(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.ListDeleteItemsParams) ([]cloudflare.ListItem, error)
+	CreateAccountAccessRule(ctx context.Context, accountID string, accessRule cloudflare.AccessRule) (*cloudflare.AccessRuleResponse, error)
+	DeleteAccountAccessRule(ctx context.Context, accountID, accessRuleID string) (*cloudflare.AccessRuleResponse, error)
+	CreateZoneAccessRule(ctx context.Context, zoneID string, accessRule cloudflare.AccessRule) (*cloudflare.AccessRuleResponse, error)
+	DeleteZoneAccessRule(ctx context.Context, zoneID, accessRuleID string) (*cloudflare.AccessRuleResponse, error)
+
+	RevokeOriginCACertificate(ctx context.Context, certificateID string) (*cloudflare.OriginCACertificateID, error)
+
+	GetOrganizationAuditLogs(ctx context.Context, organizationID string, a cloudflare.AuditLogFilter) (cloudflare.AuditLogResponse, error)
+	GetUserAuditLogs(ctx context.Context, a cloudflare.AuditLogFilter) (cloudflare.AuditLogResponse, error)
+
+	SetWorkersSecret(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.SetWorkersSecretParams) (cloudflare.WorkersPutSecretResponse, error)
+	DeleteWorkersSecret(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.DeleteWorkersSecretParams) (cloudflare.Response, error)
+}
+
+// clientFactory builds the client used to talk to Cloudflare from the mount configuration.
+type clientFactory func(config *cloudflareConfig) (cloudflareClient, error)
+
+func newClient(config *cloudflareConfig) (cloudflareClient, error) {
+	return newClientWithTransport(config, cleanhttp.DefaultPooledTransport())
 }
 
-func newClient(config *cloudflareConfig) (*cloudflareClient, error) {
+// newClientWithTransport builds a client that sends its requests through transport.
+func newClientWithTransport(config *cloudflareConfig, transport http.RoundTripper) (cloudflareClient, error) {
 	if config == nil {
 		return nil, errors.New("cloudflare client configuration was nil")
 	}
@@ -18,10 +68,21 @@ func newClient(config *cloudflareConfig) (*cloudflareClient, error) {
 		return nil, errors.New("cloudlfare API token was not defined")
 	}
 
-	c, err := cloudflare.NewWithAPIToken(config.APIToken)
+	// Retries are handled by retryTransport, which also honours Retry-After and trips a circuit
+	// breaker when Cloudflare is down, so the library's own retries are disabled.
+	opts := []cloudflare.Option{
+		cloudflare.HTTPClient(&http.Client{Transport: newRetryTransport(&metricsTransport{next: transport})}),
+		cloudflare.UsingRetryPolicy(0, 0, 0),
+	}
+
+	if config.BaseURL != "" {
+		opts = append(opts, cloudflare.BaseURL(config.BaseURL))
+	}
+
+	c, err := cloudflare.NewWithAPIToken(config.APIToken, opts...)
 
 	if err != nil {
 		return nil, err
 	}
-	return &cloudflareClient{c}, nil
+	return c, nil
 }
@@ -0,0 +1,318 @@
+package cloudflare_secrets_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+const (
+	retryMaxAttempts = 5
+	retryBaseDelay   = 500 * time.Millisecond
+	retryMaxDelay    = 30 * time.Second
+
+	// breakerThreshold is the number of consecutive failed requests after which the circuit opens.
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+var errCircuitOpen = errors.New("cloudflare API is unavailable, not sending requests until it recovers")
+
+// idempotentPostPaths match the POST endpoints that can safely be sent again. Refreshing a service
+// token only extends its expiry, so a repeated refresh has the same effect as one.
+var idempotentPostPaths = []*regexp.Regexp{
+	regexp.MustCompile(`/accounts/[^/]+/access/service_tokens/[^/]+/refresh$`),
+}
+
+// retryTransport retries requests that Cloudflare rate limited or failed to serve, honouring
+// Retry-After and otherwise backing off exponentially with jitter. It never waits past the
+// request's context deadline. Requests that are still rate limited or failing are returned as an
+// upstreamError, and count towards a circuit breaker which, once open, fails requests without
+// sending them.
+//
+// Requests that create resources, such as POSTs creating credentials, are only retried when
+// Cloudflare rate limited them or they failed before anything was sent, as a failure after that
+// may follow a create Cloudflare committed, and a retry would create a second, untracked credential.
+// POSTs to idempotentPostPaths, which only update a resource, are retried like any other update.
+type retryTransport struct {
+	next http.RoundTripper
+
+	lock      sync.Mutex
+	failures  int
+	openUntil time.Time
+	// probing is set while the single request let through a half-open circuit is in flight.
+	probing bool
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(*http.Request, time.Duration) bool
+}
+
+func newRetryTransport(next http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		next:  next,
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allow() {
+		return nil, errCircuitOpen
+	}
+
+	var resp *http.Response
+	var err error
+	body := req.Body
+
+	for attempt := 0; ; attempt++ {
+		var sent int32
+		trace := &httptrace.ClientTrace{
+			WroteHeaderField: func(string, []string) { atomic.StoreInt32(&sent, 1) },
+		}
+
+		attemptReq := req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		attemptReq.Body = body
+
+		resp, err = t.next.RoundTrip(attemptReq)
+		if !retryable(req, resp, err, atomic.LoadInt32(&sent) == 1) || attempt+1 >= retryMaxAttempts || req.Context().Err() != nil {
+			break
+		}
+
+		delay := retryDelay(resp, attempt, t.now())
+		if deadline, ok := req.Context().Deadline(); ok && t.now().Add(delay).After(deadline) {
+			break
+		}
+
+		// The last response is kept for the caller unless the body can be sent again.
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				break
+			}
+
+			var bodyErr error
+			if body, bodyErr = req.GetBody(); bodyErr != nil {
+				break
+			}
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if !t.sleep(req, delay) {
+			t.abandon()
+			return nil, req.Context().Err()
+		}
+	}
+
+	// A request that ran out of time says nothing about whether Cloudflare is up.
+	if req.Context().Err() != nil {
+		t.abandon()
+	} else {
+		t.record(resp, err)
+	}
+
+	if err == nil && failedStatus(resp.StatusCode) {
+		return nil, newUpstreamError(resp)
+	}
+
+	return resp, err
+}
+
+// upstreamError is a response that was still rate limited or failing once retries ran out. cloudflare-go
+// does not parse the body of these responses, so they are reported as errors here, carrying the
+// details needed to classify them like any other Cloudflare API error.
+type upstreamError struct {
+	statusCode int
+	rayId      string
+	errors     []cloudflare.ResponseInfo
+}
+
+func newUpstreamError(resp *http.Response) *upstreamError {
+	defer resp.Body.Close()
+
+	e := &upstreamError{
+		statusCode: resp.StatusCode,
+		rayId:      resp.Header.Get("cf-ray"),
+	}
+
+	var body cloudflare.Response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err == nil {
+		e.errors = body.Errors
+	}
+
+	return e
+}
+
+func (e *upstreamError) Error() string {
+	msg := fmt.Sprintf("cloudflare API returned HTTP %d", e.statusCode)
+	for _, info := range e.errors {
+		msg += fmt.Sprintf(", %s (%d)", info.Message, info.Code)
+	}
+	return msg
+}
+
+func (e *upstreamError) Type() cloudflare.ErrorType {
+	if e.statusCode == http.StatusTooManyRequests {
+		return cloudflare.ErrorTypeRateLimit
+	}
+	return cloudflare.ErrorTypeService
+}
+
+func (e *upstreamError) ErrorCodes() []int {
+	codes := make([]int, len(e.errors))
+	for i, info := range e.errors {
+		codes[i] = info.Code
+	}
+	return codes
+}
+
+func (e *upstreamError) RayID() string {
+	return e.rayId
+}
+
+// allow reports whether a request may be sent. Once the cooldown of an open circuit has passed it is
+// half-open: a single probe request is let through, and the rest fail fast until the probe succeeds
+// and closes the circuit, or fails and reopens it.
+func (t *retryTransport) allow() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.now().Before(t.openUntil) {
+		return false
+	}
+
+	if t.failures < breakerThreshold {
+		return true
+	}
+
+	if t.probing {
+		return false
+	}
+
+	t.probing = true
+	return true
+}
+
+// record counts the outcome of a request towards the circuit breaker. Rate limited requests show
+// that Cloudflare is up and reset the count, like any other response below 500.
+func (t *retryTransport) record(resp *http.Response, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.probing = false
+
+	if err == nil && resp.StatusCode < http.StatusInternalServerError {
+		t.failures = 0
+		return
+	}
+
+	t.failures++
+	if t.failures >= breakerThreshold {
+		t.openUntil = t.now().Add(breakerCooldown)
+	}
+}
+
+// abandon ends a request that was cancelled by its caller without counting it, letting another
+// probe through if it was one.
+func (t *retryTransport) abandon() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.probing = false
+}
+
+// retryable reports whether a request should be sent again. Rate limited requests never reached
+// Cloudflare's handlers and are always retried. Failures are only retried for idempotent methods
+// and idempotent POSTs, or, for other requests, when nothing of the request was sent.
+func retryable(req *http.Request, resp *http.Response, err error, sent bool) bool {
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if err == nil && !failedStatus(resp.StatusCode) {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return idempotentPost(req) || (err != nil && !sent)
+	default:
+		return err != nil && !sent
+	}
+}
+
+// idempotentPost reports whether a POST request is to one of the idempotentPostPaths.
+func idempotentPost(req *http.Request) bool {
+	for _, path := range idempotentPostPaths {
+		if path.MatchString(req.URL.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// failedStatus reports whether a response is one Cloudflare rate limited or failed to serve.
+func failedStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryDelay returns how long to wait before the next attempt, taken from the Retry-After header
+// if the response has one, or an exponential backoff with jitter.
+func retryDelay(resp *http.Response, attempt int, now time.Time) time.Duration {
+	if resp != nil {
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+				return minDuration(time.Duration(seconds)*time.Second, retryMaxDelay)
+			}
+
+			if at, err := http.ParseTime(retryAfter); err == nil {
+				return minDuration(maxDuration(at.Sub(now), 0), retryMaxDelay)
+			}
+		}
+	}
+
+	backoff := minDuration(retryBaseDelay<<attempt, retryMaxDelay)
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func sleepContext(req *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
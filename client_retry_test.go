@@ -0,0 +1,309 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRetryTransport(server *httptest.Server) (*retryTransport, *time.Time, *[]time.Duration) {
+	now := time.Now()
+	var slept []time.Duration
+
+	t := newRetryTransport(server.Client().Transport)
+	t.now = func() time.Time { return now }
+	t.sleep = func(_ *http.Request, d time.Duration) bool {
+		slept = append(slept, d)
+		return true
+	}
+
+	return t, &now, &slept
+}
+
+func TestRetryTransport(t *testing.T) {
+	t.Run("Retry After", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			require.Equal(t, `{"name":"test"}`, string(body))
+
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "3")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		transport, _, slept := newTestRetryTransport(server)
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"name":"test"}`))
+		require.NoError(t, err)
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		require.Equal(t, []time.Duration{3 * time.Second}, *slept)
+	})
+
+	t.Run("Backoff", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		transport, _, slept := newTestRetryTransport(server)
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		_, err = transport.RoundTrip(req)
+		var upstreamErr *upstreamError
+		require.ErrorAs(t, err, &upstreamErr)
+		require.Equal(t, http.StatusBadGateway, upstreamErr.statusCode)
+		require.Equal(t, int32(retryMaxAttempts), atomic.LoadInt32(&calls))
+		require.Len(t, *slept, retryMaxAttempts-1)
+
+		for i, d := range *slept {
+			backoff := retryBaseDelay << i
+			require.GreaterOrEqual(t, d, backoff/2)
+			require.LessOrEqual(t, d, backoff)
+		}
+	})
+
+	t.Run("Do Not Retry Failed Creates", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		transport, _, slept := newTestRetryTransport(server)
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"name":"test"}`))
+		require.NoError(t, err)
+
+		_, err = transport.RoundTrip(req)
+		var upstreamErr *upstreamError
+		require.ErrorAs(t, err, &upstreamErr)
+		require.Equal(t, http.StatusBadGateway, upstreamErr.statusCode)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		require.Empty(t, *slept)
+	})
+
+	t.Run("Retry Service Token Refreshes", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		transport, _, slept := newTestRetryTransport(server)
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/client/v4/accounts/a/access/service_tokens/t/refresh", nil)
+		require.NoError(t, err)
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		require.Len(t, *slept, 1)
+	})
+
+	t.Run("Retry Creates That Were Not Sent", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		transport, _, _ := newTestRetryTransport(server)
+		failures := 0
+		transport.next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if failures < 2 {
+				failures++
+				return nil, errors.New("dial tcp: connection refused")
+			}
+			return server.Client().Transport.RoundTrip(req)
+		})
+
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"name":"test"}`))
+		require.NoError(t, err)
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Keep The Last Response If The Body Cannot Be Replayed", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"success":false,"errors":[{"code":10000,"message":"Rate limited"}]}`))
+		}))
+		defer server.Close()
+
+		transport, _, _ := newTestRetryTransport(server)
+		req, err := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader(`{"name":"test"}`)))
+		require.NoError(t, err)
+		require.Nil(t, req.GetBody)
+
+		_, err = transport.RoundTrip(req)
+		var upstreamErr *upstreamError
+		require.ErrorAs(t, err, &upstreamErr)
+		require.Equal(t, []int{10000}, upstreamErr.ErrorCodes())
+	})
+
+	t.Run("Client Error", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		transport, _, _ := newTestRetryTransport(server)
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Retry-After", "20")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		transport := newRetryTransport(server.Client().Transport)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		_, err = transport.RoundTrip(req)
+		var upstreamErr *upstreamError
+		require.ErrorAs(t, err, &upstreamErr)
+		require.Equal(t, http.StatusTooManyRequests, upstreamErr.statusCode)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Cancelled Requests Do Not Count Towards The Breaker", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		transport, _, _ := newTestRetryTransport(server)
+		transport.failures = breakerThreshold - 1
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		_, err = transport.RoundTrip(req)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, breakerThreshold-1, transport.failures)
+	})
+
+	t.Run("Circuit Breaker", func(t *testing.T) {
+		var calls int32
+		var down int32 = 1
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if atomic.LoadInt32(&down) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		transport, now, _ := newTestRetryTransport(server)
+
+		for i := 0; i < breakerThreshold; i++ {
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+
+			_, err = transport.RoundTrip(req)
+			var upstreamErr *upstreamError
+			require.ErrorAs(t, err, &upstreamErr)
+			require.Equal(t, http.StatusServiceUnavailable, upstreamErr.statusCode)
+		}
+
+		calls = 0
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		_, err = transport.RoundTrip(req)
+		require.ErrorIs(t, err, errCircuitOpen)
+		require.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+		// Once the cooldown passes, a single probe is let through.
+		*now = now.Add(breakerCooldown)
+		require.True(t, transport.allow())
+		require.False(t, transport.allow())
+		transport.abandon()
+
+		_, err = transport.RoundTrip(req)
+		require.Error(t, err)
+		require.Equal(t, int32(retryMaxAttempts), atomic.LoadInt32(&calls))
+
+		_, err = transport.RoundTrip(req)
+		require.ErrorIs(t, err, errCircuitOpen)
+
+		atomic.StoreInt32(&down, 0)
+		*now = now.Add(breakerCooldown)
+
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 0, transport.failures)
+		require.True(t, transport.allow())
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", now.Add(10*time.Second).UTC().Format(http.TimeFormat))
+	require.Equal(t, 10*time.Second, retryDelay(resp, 0, now))
+
+	resp.Header.Set("Retry-After", "3600")
+	require.Equal(t, retryMaxDelay, retryDelay(resp, 0, now))
+
+	delay := retryDelay(nil, 20, now)
+	require.GreaterOrEqual(t, delay, retryMaxDelay/2)
+	require.LessOrEqual(t, delay, retryMaxDelay)
+}
@@ -0,0 +1,378 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareAPITokenType = "cloudflare_api_token"
+
+	tokenOwnerUser    = "user"
+	tokenOwnerAccount = "account"
+
+	accountResourcePrefix = "com.cloudflare.api.account."
+	zoneResourcePrefix    = "com.cloudflare.api.account.zone."
+	zoneResourceWildcard  = zoneResourcePrefix + "*"
+)
+
+type cloudflareAPIToken struct {
+	TokenID   string    `json:"token_id"`
+	TokenName string    `json:"token_name"`
+	Token     string    `json:"token"`
+	ExpiresOn time.Time `json:"expires_on"`
+}
+
+func (token *cloudflareAPIToken) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"token_id":   token.TokenID,
+		"token_name": token.TokenName,
+		"token":      token.Token,
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareAPIToken() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareAPITokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"token_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare API Token ID",
+			},
+			"token_name": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare API Token Name",
+			},
+			"token": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare API Token",
+			},
+		},
+		Revoke: b.apiTokenRevoke,
+		Renew:  b.apiTokenRenew,
+	}
+}
+
+func (b *cloudflareBackend) apiTokenRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenIdRaw, ok := req.Secret.InternalData["token_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing token_id internal data")
+	}
+
+	tokenOwnerRaw, ok := req.Secret.InternalData["token_owner"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing token_owner internal data")
+	}
+
+	accountIdRaw, ok := req.Secret.InternalData["account_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing account_id internal data")
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	if err := deleteAPIToken(ctx, client, tokenOwnerRaw.(string), accountIdRaw.(string), tokenIdRaw.(string)); err != nil {
+		return nil, fmt.Errorf("error revoking api token: %w", err)
+	}
+
+	if roleName, activeId, ok := leaseActiveCredentials(req.Secret); ok {
+		if err := b.releaseActiveCredentials(ctx, req.Storage, roleName, activeId, leaseCredentialCount(req.Secret)); err != nil {
+			return nil, fmt.Errorf("error releasing max_active reservation: %w", err)
+		}
+	}
+
+	if err := forgetIssuedCredentials(ctx, req.Storage, leaseTokenIds(req.Secret)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *cloudflareBackend) apiTokenRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenIdRaw, ok := req.Secret.InternalData["token_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing token_id internal data")
+	}
+
+	tokenOwnerRaw, ok := req.Secret.InternalData["token_owner"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing token_owner internal data")
+	}
+
+	accountIdRaw, ok := req.Secret.InternalData["account_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing account_id internal data")
+	}
+
+	ttl, _, err := framework.CalculateTTL(b.System(), req.Secret.Increment, 0, 0, req.Secret.MaxTTL, 0, req.Secret.IssueTime)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	if err := renewAPIToken(ctx, client, tokenOwnerRaw.(string), accountIdRaw.(string), tokenIdRaw.(string), apiTokenExpiry(ttl)); err != nil {
+		return nil, fmt.Errorf("error renewing api token: %w", err)
+	}
+
+	if roleName, activeId, ok := leaseActiveCredentials(req.Secret); ok {
+		if err := b.renewActiveCredentials(ctx, req.Storage, roleName, activeId, req.Secret); err != nil {
+			return nil, fmt.Errorf("error renewing max_active reservation: %w", err)
+		}
+	}
+
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = ttl
+
+	return resp, nil
+}
+
+// apiTokenExpiry returns the expiry of a token valid for ttl, truncated to the second precision Cloudflare accepts.
+func apiTokenExpiry(ttl time.Duration) time.Time {
+	return time.Now().Add(ttl).UTC().Truncate(time.Second)
+}
+
+// apiTokenPolicies parses the JSON encoded token policies of a role.
+func apiTokenPolicies(policies string) ([]cloudflare.APITokenPolicies, error) {
+	var parsed []cloudflare.APITokenPolicies
+	if err := json.Unmarshal([]byte(policies), &parsed); err != nil {
+		return nil, err
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("at least one policy is required")
+	}
+
+	return parsed, nil
+}
+
+// narrowAPITokenPolicies restricts the allow policies of a role to the requested zones and permission
+// groups, and fails if any of them is not granted by the role. Deny policies are kept unchanged.
+func narrowAPITokenPolicies(policies []cloudflare.APITokenPolicies, zones []string, permissionGroups []string) ([]cloudflare.APITokenPolicies, error) {
+	if len(zones) == 0 && len(permissionGroups) == 0 {
+		return policies, nil
+	}
+
+	var narrowed []cloudflare.APITokenPolicies
+	grantedZones := make(map[string]bool)
+	grantedGroups := make(map[string]bool)
+	allowed := false
+
+	for _, policy := range policies {
+		if policy.Effect != "allow" {
+			narrowed = append(narrowed, policy)
+			continue
+		}
+
+		if len(permissionGroups) > 0 {
+			var groups []cloudflare.APITokenPermissionGroups
+			for _, group := range policy.PermissionGroups {
+				if strutil.StrListContains(permissionGroups, group.ID) {
+					groups = append(groups, group)
+				}
+			}
+			if len(groups) == 0 {
+				continue
+			}
+			policy.PermissionGroups = groups
+		}
+
+		if len(zones) > 0 {
+			resources, granted := narrowZoneResources(policy.Resources, zones)
+			if len(resources) == 0 {
+				continue
+			}
+			policy.Resources = resources
+			for _, zone := range granted {
+				grantedZones[zone] = true
+			}
+		}
+
+		for _, group := range policy.PermissionGroups {
+			grantedGroups[group.ID] = true
+		}
+
+		narrowed = append(narrowed, policy)
+		allowed = true
+	}
+
+	for _, zone := range zones {
+		if !grantedZones[zone] {
+			return nil, fmt.Errorf("zone %q is not granted by the role", zone)
+		}
+	}
+
+	for _, group := range permissionGroups {
+		if !grantedGroups[group] {
+			return nil, fmt.Errorf("permission group %q is not granted by the role", group)
+		}
+	}
+
+	if !allowed {
+		return nil, fmt.Errorf("no role policy grants the requested zones and permission groups")
+	}
+
+	return narrowed, nil
+}
+
+// narrowZoneResources restricts policy resources to the requested zones that they include, either
+// directly or by wildcard. Zones within an account resource stay nested under that account.
+func narrowZoneResources(resources map[string]interface{}, zones []string) (map[string]interface{}, []string) {
+	narrowed := make(map[string]interface{})
+	var granted []string
+
+	for _, zone := range zones {
+		if zoneResourceIncluded(resources, zone) {
+			narrowed[zoneResourcePrefix+zone] = "*"
+			granted = append(granted, zone)
+		}
+	}
+
+	for key, value := range resources {
+		nested, ok := value.(map[string]interface{})
+		if !ok || !strings.HasPrefix(key, accountResourcePrefix) || strings.HasPrefix(key, zoneResourcePrefix) {
+			continue
+		}
+
+		account := make(map[string]interface{})
+		for _, zone := range zones {
+			if zoneResourceIncluded(nested, zone) {
+				account[zoneResourcePrefix+zone] = "*"
+				granted = append(granted, zone)
+			}
+		}
+
+		if len(account) > 0 {
+			narrowed[key] = account
+		}
+	}
+
+	return narrowed, granted
+}
+
+func zoneResourceIncluded(resources map[string]interface{}, zone string) bool {
+	return resources[zoneResourcePrefix+zone] == "*" || resources[zoneResourceWildcard] == "*"
+}
+
+// apiTokenCondition builds the request IP condition of a token issued to req, or nil if the
+// role does not restrict where its tokens can be used from.
+func apiTokenCondition(req *logical.Request, role *cloudflareRoleEntry) (*cloudflare.APITokenCondition, error) {
+	allowed := role.TokenAllowedCIDRs
+
+	if role.BindToRequesterIP {
+		prefix, err := requesterPrefix(req, requesterIPv6PrefixLength)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(allowed) > 0 && !prefixWithin(prefix, allowed) {
+			return nil, fmt.Errorf("requester address %s is not within token_allowed_cidrs", prefix)
+		}
+
+		if prefixWithin(prefix, role.TokenDeniedCIDRs) {
+			return nil, fmt.Errorf("requester address %s is within token_denied_cidrs", prefix)
+		}
+
+		allowed = []string{prefix.String()}
+	}
+
+	if len(allowed) == 0 && len(role.TokenDeniedCIDRs) == 0 {
+		return nil, nil
+	}
+
+	return &cloudflare.APITokenCondition{
+		RequestIP: &cloudflare.APITokenRequestIPCondition{
+			In:    allowed,
+			NotIn: role.TokenDeniedCIDRs,
+		},
+	}, nil
+}
+
+// apiTokenPath returns the endpoint of account owned API tokens, which cloudflare-go does not yet wrap.
+func apiTokenPath(accountId string, tokenId string) string {
+	uri := fmt.Sprintf("/accounts/%s/tokens", accountId)
+	if tokenId != "" {
+		uri += "/" + tokenId
+	}
+	return uri
+}
+
+func createAPIToken(ctx context.Context, c cloudflareClient, owner string, accountId string, request cloudflare.APIToken) (*cloudflareAPIToken, error) {
+	var response cloudflare.APIToken
+	var err error
+
+	switch owner {
+	case tokenOwnerAccount:
+		var raw json.RawMessage
+		raw, err = c.Raw(ctx, http.MethodPost, apiTokenPath(accountId, ""), request, nil)
+		if err == nil {
+			err = json.Unmarshal(raw, &response)
+		}
+	default:
+		response, err = c.CreateAPIToken(ctx, request)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s api token: %w", owner, err)
+	}
+
+	return &cloudflareAPIToken{
+		TokenID:   response.ID,
+		TokenName: response.Name,
+		Token:     response.Value,
+		ExpiresOn: *request.ExpiresOn,
+	}, nil
+}
+
+func renewAPIToken(ctx context.Context, c cloudflareClient, owner string, accountId string, tokenId string, expiresOn time.Time) error {
+	switch owner {
+	case tokenOwnerAccount:
+		raw, err := c.Raw(ctx, http.MethodGet, apiTokenPath(accountId, tokenId), nil, nil)
+		if err != nil {
+			return err
+		}
+
+		var token cloudflare.APIToken
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return err
+		}
+
+		token.ExpiresOn = &expiresOn
+		_, err = c.Raw(ctx, http.MethodPut, apiTokenPath(accountId, tokenId), token, nil)
+		return err
+	default:
+		token, err := c.GetAPIToken(ctx, tokenId)
+		if err != nil {
+			return err
+		}
+
+		token.ExpiresOn = &expiresOn
+		_, err = c.UpdateAPIToken(ctx, tokenId, token)
+		return err
+	}
+}
+
+func deleteAPIToken(ctx context.Context, c cloudflareClient, owner string, accountId string, tokenId string) error {
+	switch owner {
+	case tokenOwnerAccount:
+		_, err := c.Raw(ctx, http.MethodDelete, apiTokenPath(accountId, tokenId), nil, nil)
+		return err
+	default:
+		return c.DeleteAPIToken(ctx, tokenId)
+	}
+}
@@ -0,0 +1,134 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokenCondition(t *testing.T) {
+	req := &logical.Request{
+		Connection: &logical.Connection{RemoteAddr: "203.0.113.10:51234"},
+	}
+
+	condition, err := apiTokenCondition(req, &cloudflareRoleEntry{})
+	require.NoError(t, err)
+	require.Nil(t, condition)
+
+	condition, err = apiTokenCondition(req, &cloudflareRoleEntry{
+		TokenAllowedCIDRs: []string{"203.0.113.0/24"},
+		TokenDeniedCIDRs:  []string{"203.0.113.128/25"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"203.0.113.0/24"}, condition.RequestIP.In)
+	require.Equal(t, []string{"203.0.113.128/25"}, condition.RequestIP.NotIn)
+
+	condition, err = apiTokenCondition(req, &cloudflareRoleEntry{
+		TokenAllowedCIDRs: []string{"203.0.113.0/24"},
+		BindToRequesterIP: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"203.0.113.10/32"}, condition.RequestIP.In)
+
+	_, err = apiTokenCondition(req, &cloudflareRoleEntry{
+		TokenAllowedCIDRs: []string{"198.51.100.0/24"},
+		BindToRequesterIP: true,
+	})
+	require.Error(t, err)
+
+	_, err = apiTokenCondition(req, &cloudflareRoleEntry{
+		TokenDeniedCIDRs:  []string{"203.0.113.0/24"},
+		BindToRequesterIP: true,
+	})
+	require.Error(t, err)
+
+	_, err = apiTokenCondition(&logical.Request{}, &cloudflareRoleEntry{BindToRequesterIP: true})
+	require.Error(t, err)
+}
+
+func TestNarrowAPITokenPolicies(t *testing.T) {
+	policies := []cloudflare.APITokenPolicies{
+		{
+			Effect: "allow",
+			Resources: map[string]interface{}{
+				"com.cloudflare.api.account.zone.*": "*",
+			},
+			PermissionGroups: []cloudflare.APITokenPermissionGroups{{ID: "dnsread"}, {ID: "dnswrite"}},
+		},
+		{
+			Effect: "allow",
+			Resources: map[string]interface{}{
+				"com.cloudflare.api.account.testaccountid": map[string]interface{}{
+					"com.cloudflare.api.account.zone.zone1": "*",
+				},
+			},
+			PermissionGroups: []cloudflare.APITokenPermissionGroups{{ID: "cachepurge"}},
+		},
+		{
+			Effect: "deny",
+			Resources: map[string]interface{}{
+				"com.cloudflare.api.account.zone.zone3": "*",
+			},
+			PermissionGroups: []cloudflare.APITokenPermissionGroups{{ID: "dnswrite"}},
+		},
+	}
+
+	narrowed, err := narrowAPITokenPolicies(policies, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, policies, narrowed)
+
+	narrowed, err = narrowAPITokenPolicies(policies, []string{"zone1"}, []string{"dnsread"})
+	require.NoError(t, err)
+	require.Len(t, narrowed, 2)
+	require.Equal(t, map[string]interface{}{"com.cloudflare.api.account.zone.zone1": "*"}, narrowed[0].Resources)
+	require.Equal(t, []cloudflare.APITokenPermissionGroups{{ID: "dnsread"}}, narrowed[0].PermissionGroups)
+	require.Equal(t, "deny", narrowed[1].Effect)
+
+	narrowed, err = narrowAPITokenPolicies(policies, []string{"zone1"}, []string{"cachepurge"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"com.cloudflare.api.account.testaccountid": map[string]interface{}{
+			"com.cloudflare.api.account.zone.zone1": "*",
+		},
+	}, narrowed[0].Resources)
+
+	_, err = narrowAPITokenPolicies(policies, nil, []string{"accountadmin"})
+	require.Error(t, err)
+
+	_, err = narrowAPITokenPolicies(policies[1:], []string{"zone2"}, nil)
+	require.Error(t, err)
+}
+
+func TestAPITokenLifecycle(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "zone-read", map[string]interface{}{
+		"credential_type": "api",
+		"policies":        `[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.*":"*"},"permission_groups":[{"id":"c8fed203ed3043cba015a93ad1616f1f"}]}]`,
+	})
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "api-token/zone-read",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Secret)
+
+	tokenId := resp.Data["token_id"].(string)
+	require.Contains(t, client.apiTokens, tokenId)
+
+	_, err = testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), *client.apiTokens[tokenId].ExpiresOn, time.Minute)
+
+	_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+	require.NoError(t, err)
+	require.NotContains(t, client.apiTokens, tokenId)
+}
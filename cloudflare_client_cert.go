@@ -0,0 +1,185 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareClientCertificateType = "cloudflare_client_certificate"
+
+	clientCertMaxValidityDays = 3650
+)
+
+type cloudflareClientCertificate struct {
+	CertificateID          string    `json:"certificate_id"`
+	Certificate            string    `json:"certificate"`
+	CertificateAuthorityID string    `json:"certificate_authority_id"`
+	SerialNumber           string    `json:"serial_number"`
+	Expiration             time.Time `json:"expiration"`
+	PrivateKey             string    `json:"private_key,omitempty"`
+	PrivateKeyType         string    `json:"private_key_type,omitempty"`
+}
+
+// clientCertificateResponse is the result of the API Shield client certificates endpoint,
+// which cloudflare-go does not yet wrap.
+type clientCertificateResponse struct {
+	ID                   string `json:"id"`
+	Certificate          string `json:"certificate"`
+	SerialNumber         string `json:"serial_number"`
+	ExpiresOn            string `json:"expires_on"`
+	CertificateAuthority struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"certificate_authority"`
+}
+
+type createClientCertificateRequest struct {
+	CSR          string `json:"csr"`
+	ValidityDays int    `json:"validity_days"`
+}
+
+func (cert *cloudflareClientCertificate) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"certificate_id":           cert.CertificateID,
+		"certificate":              cert.Certificate,
+		"certificate_authority_id": cert.CertificateAuthorityID,
+		"serial_number":            cert.SerialNumber,
+		"expiration":               cert.Expiration.Unix(),
+	}
+	if cert.PrivateKey != "" {
+		respData["private_key"] = cert.PrivateKey
+		respData["private_key_type"] = cert.PrivateKeyType
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareClientCertificate() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareClientCertificateType,
+		Fields: map[string]*framework.FieldSchema{
+			"certificate_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare API Shield Client Certificate ID",
+			},
+			"certificate": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare API Shield Client Certificate in PEM format",
+			},
+			"certificate_authority_id": {
+				Type:        framework.TypeString,
+				Description: "ID of the Cloudflare managed CA that issued the certificate",
+			},
+			"serial_number": {
+				Type:        framework.TypeString,
+				Description: "Serial number of the certificate",
+			},
+			"expiration": {
+				Type:        framework.TypeInt64,
+				Description: "Expiration of the certificate as a unix timestamp",
+			},
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: "Private key in PEM format, only returned when generated by Vault",
+			},
+			"private_key_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the generated private key",
+			},
+		},
+		Revoke: b.clientCertificateRevoke,
+		Renew:  b.clientCertificateRenew,
+	}
+}
+
+func (b *cloudflareBackend) clientCertificateRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	certificateIdRaw, ok := req.Secret.InternalData["certificate_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing certificate_id internal data")
+	}
+
+	zoneIdRaw, ok := req.Secret.InternalData["zone_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing zone_id internal data")
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	if err := deleteClientCertificate(ctx, client, zoneIdRaw.(string), certificateIdRaw.(string)); err != nil {
+		return nil, fmt.Errorf("error revoking client certificate: %w", err)
+	}
+	return nil, nil
+}
+
+func (b *cloudflareBackend) clientCertificateRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	expirationRaw, ok := req.Secret.InternalData["expiration"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing expiration internal data")
+	}
+
+	expiration, err := time.Parse(time.RFC3339, expirationRaw.(string))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificate expiration: %w", err)
+	}
+
+	remaining := time.Until(expiration)
+	if remaining <= 0 {
+		return nil, fmt.Errorf("client certificate has expired")
+	}
+
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.MaxTTL = remaining
+	if resp.Secret.TTL > remaining {
+		resp.Secret.TTL = remaining
+	}
+
+	return resp, nil
+}
+
+func createClientCertificate(ctx context.Context, c cloudflareClient, zoneId string, csr string, validityDays int) (*cloudflareClientCertificate, error) {
+	uri := fmt.Sprintf("/zones/%s/client_certificates", zoneId)
+	raw, err := c.Raw(ctx, http.MethodPost, uri, createClientCertificateRequest{
+		CSR:          csr,
+		ValidityDays: validityDays,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating client certificate: %w", err)
+	}
+
+	var response clientCertificateResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, fmt.Errorf("error decoding client certificate: %w", err)
+	}
+
+	expiration, err := time.Parse(time.RFC3339, response.ExpiresOn)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing client certificate expiration: %w", err)
+	}
+
+	return &cloudflareClientCertificate{
+		CertificateID:          response.ID,
+		Certificate:            response.Certificate,
+		CertificateAuthorityID: response.CertificateAuthority.ID,
+		SerialNumber:           response.SerialNumber,
+		Expiration:             expiration,
+	}, nil
+}
+
+func deleteClientCertificate(ctx context.Context, c cloudflareClient, zoneId string, certificateId string) error {
+	uri := fmt.Sprintf("/zones/%s/client_certificates/%s", zoneId, certificateId)
+	_, err := c.Raw(ctx, http.MethodDelete, uri, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
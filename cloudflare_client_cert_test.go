@@ -0,0 +1,59 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCertificateLifecycle(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "devices", map[string]interface{}{
+		"credential_type": "client-cert",
+		"zone_id":         fakeZoneId,
+		"key_type":        "ecdsa",
+		"validity_days":   30,
+	})
+	require.NoError(t, err)
+
+	issue := func(data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "client-cert/devices",
+			Data:      data,
+			Storage:   s,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("Issue, Renew And Revoke", func(t *testing.T) {
+		resp := issue(map[string]interface{}{"common_name": "device-1"})
+		require.False(t, resp.IsError(), resp.Error())
+		require.NotNil(t, resp.Secret)
+
+		certificateId := resp.Data["certificate_id"].(string)
+		require.Equal(t, fakeZoneId, client.clientCerts[certificateId])
+		require.Equal(t, "managed-ca", resp.Data["certificate_authority_id"])
+		require.NotEmpty(t, resp.Data["private_key"])
+		require.Equal(t, "ecdsa", resp.Data["private_key_type"])
+
+		renewed, err := testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.NoError(t, err)
+		require.LessOrEqual(t, renewed.Secret.MaxTTL, resp.Secret.MaxTTL)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, client.clientCerts, certificateId)
+	})
+
+	t.Run("Reject Missing Common Name", func(t *testing.T) {
+		resp := issue(nil)
+		require.True(t, resp.IsError())
+		require.Empty(t, client.clientCerts)
+	})
+}
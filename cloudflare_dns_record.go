@@ -0,0 +1,130 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareDNSRecordType = "cloudflare_dns_record"
+)
+
+// dnsRecordTypes are the record types a dns-record role may allow.
+var dnsRecordTypes = []string{"TXT", "CNAME"}
+
+type cloudflareDNSRecord struct {
+	RecordID string `json:"record_id"`
+	ZoneID   string `json:"zone_id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	TTL      int    `json:"ttl"`
+}
+
+func (record *cloudflareDNSRecord) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"record_id": record.RecordID,
+		"zone_id":   record.ZoneID,
+		"type":      record.Type,
+		"name":      record.Name,
+		"content":   record.Content,
+		"ttl":       record.TTL,
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareDNSRecord() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareDNSRecordType,
+		Fields: map[string]*framework.FieldSchema{
+			"record_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare DNS Record ID",
+			},
+			"zone_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare Zone ID the record was created in",
+			},
+			"type": {
+				Type:        framework.TypeString,
+				Description: "DNS Record Type",
+			},
+			"name": {
+				Type:        framework.TypeString,
+				Description: "DNS Record Name",
+			},
+			"content": {
+				Type:        framework.TypeString,
+				Description: "DNS Record Content",
+			},
+			"ttl": {
+				Type:        framework.TypeInt,
+				Description: "DNS Record TTL in seconds",
+			},
+		},
+		Revoke: b.dnsRecordRevoke,
+		Renew:  b.dnsRecordRenew,
+	}
+}
+
+func (b *cloudflareBackend) dnsRecordRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	recordIdRaw, ok := req.Secret.InternalData["record_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing record_id internal data")
+	}
+
+	zoneIdRaw, ok := req.Secret.InternalData["zone_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing zone_id internal data")
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	// A record already deleted, by hand or by an earlier revocation, needs no further cleanup.
+	var notFoundErr *cloudflare.NotFoundError
+	err = deleteDNSRecord(ctx, client, zoneIdRaw.(string), recordIdRaw.(string))
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return nil, fmt.Errorf("error revoking dns record: %w", err)
+	}
+	return nil, nil
+}
+
+func (b *cloudflareBackend) dnsRecordRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp := &logical.Response{Secret: req.Secret}
+
+	return resp, nil
+}
+
+func createDNSRecord(ctx context.Context, c cloudflareClient, zoneId string, recordType string, name string, content string, ttl int) (*cloudflareDNSRecord, error) {
+	response, err := c.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneId), cloudflare.CreateDNSRecordParams{
+		Type:    recordType,
+		Name:    name,
+		Content: content,
+		TTL:     ttl,
+		Comment: "Managed by Vault",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating dns record: %w", err)
+	}
+
+	return &cloudflareDNSRecord{
+		RecordID: response.ID,
+		ZoneID:   zoneId,
+		Type:     response.Type,
+		Name:     response.Name,
+		Content:  response.Content,
+		TTL:      response.TTL,
+	}, nil
+}
+
+func deleteDNSRecord(ctx context.Context, c cloudflareClient, zoneId string, recordId string) error {
+	return c.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zoneId), recordId)
+}
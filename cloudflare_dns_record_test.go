@@ -0,0 +1,103 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func testDNSRecordWrite(t *testing.T, b *cloudflareBackend, s logical.Storage, name string, data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "dns-record/" + name,
+		Data:      data,
+		Storage:   s,
+	})
+}
+
+func TestDNSRecordLifecycle(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "acme", map[string]interface{}{
+		"credential_type":      "dns-record",
+		"zone_id":              fakeZoneId,
+		"allowed_record_types": "TXT,CNAME",
+		"allowed_record_names": "_acme-challenge.*.example.com",
+		"allowed_content":      "*.acme.example.net",
+	})
+	require.NoError(t, err)
+
+	t.Run("Issue, Renew And Revoke", func(t *testing.T) {
+		resp, err := testDNSRecordWrite(t, b, s, "acme", map[string]interface{}{
+			"record_name": "_acme-challenge.www.example.com",
+			"record_type": "TXT",
+			"content":     "challenge",
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+
+		recordId := resp.Data["record_id"].(string)
+		require.Equal(t, "challenge", client.records[recordId].Content)
+		require.Equal(t, fakeZoneId, client.records[recordId].ZoneID)
+
+		_, err = testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.NoError(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, client.records, recordId)
+	})
+
+	t.Run("Revoke Records Deleted By Hand", func(t *testing.T) {
+		resp, err := testDNSRecordWrite(t, b, s, "acme", map[string]interface{}{
+			"record_name": "_acme-challenge.www.example.com",
+			"record_type": "TXT",
+			"content":     "challenge",
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+
+		delete(client.records, resp.Data["record_id"].(string))
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+	})
+
+	t.Run("Record Name Globs Match A Single Label", func(t *testing.T) {
+		for _, recordName := range []string{"_acme-challenge.a.b.example.com", "_acme-challenge.example.com"} {
+			resp, err := testDNSRecordWrite(t, b, s, "acme", map[string]interface{}{
+				"record_name": recordName,
+				"record_type": "TXT",
+				"content":     "challenge",
+			})
+			require.NoError(t, err)
+			require.True(t, resp.IsError(), recordName)
+		}
+		require.Empty(t, client.records)
+	})
+
+	t.Run("Restrict CNAME Targets", func(t *testing.T) {
+		resp, err := testDNSRecordWrite(t, b, s, "acme", map[string]interface{}{
+			"record_name": "_acme-challenge.www.example.com",
+			"record_type": "CNAME",
+			"content":     "www.acme.example.net.",
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+		require.Equal(t, "www.acme.example.net", resp.Data["content"])
+
+		for _, content := range []string{"attacker.example.org", "a.b.acme.example.net"} {
+			resp, err = testDNSRecordWrite(t, b, s, "acme", map[string]interface{}{
+				"record_name": "_acme-challenge.www.example.com",
+				"record_type": "CNAME",
+				"content":     content,
+			})
+			require.NoError(t, err)
+			require.True(t, resp.IsError(), content)
+		}
+	})
+}
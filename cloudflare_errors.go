@@ -0,0 +1,65 @@
+package cloudflare_secrets_engine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// cloudflareAPIError is implemented by each of the error types cloudflare-go returns for API errors.
+type cloudflareAPIError interface {
+	error
+	Type() cloudflare.ErrorType
+	ErrorCodes() []int
+	RayID() string
+}
+
+// cloudflareErrorStatus returns the status code a Cloudflare API error is reported with, so callers can
+// tell a misconfigured role from Cloudflare being unavailable.
+func cloudflareErrorStatus(apiErr cloudflareAPIError) int {
+	switch apiErr.Type() {
+	case cloudflare.ErrorTypeAuthentication, cloudflare.ErrorTypeAuthorization:
+		return http.StatusForbidden
+	case cloudflare.ErrorTypeRequest, cloudflare.ErrorTypeNotFound:
+		return http.StatusBadRequest
+	case cloudflare.ErrorTypeRateLimit:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// cloudflareErrorResponse converts an error creating credentials into a response with a status code
+// matching its cause, with the Cloudflare error codes and ray ID as warnings. Errors that did not come
+// from Cloudflare are returned unchanged.
+func cloudflareErrorResponse(req *logical.Request, err error) (*logical.Response, error) {
+	var apiErr cloudflareAPIError
+	if errors.As(err, &apiErr) {
+		resp := logical.ErrorResponse(err.Error())
+
+		if codes := apiErr.ErrorCodes(); len(codes) > 0 {
+			values := make([]string, len(codes))
+			for i, code := range codes {
+				values[i] = strconv.Itoa(code)
+			}
+			resp.AddWarning(fmt.Sprintf("cloudflare error codes: %s", strings.Join(values, ", ")))
+		}
+
+		if rayId := apiErr.RayID(); rayId != "" {
+			resp.AddWarning(fmt.Sprintf("cloudflare ray id: %s", rayId))
+		}
+
+		return logical.RespondWithStatusCode(resp, req, cloudflareErrorStatus(apiErr))
+	}
+
+	if errors.Is(err, errCircuitOpen) {
+		return logical.RespondWithStatusCode(logical.ErrorResponse(err.Error()), req, http.StatusBadGateway)
+	}
+
+	return nil, err
+}
@@ -0,0 +1,75 @@
+package cloudflare_secrets_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudflareErrorResponse(t *testing.T) {
+	req := &logical.Request{ID: "test"}
+
+	testCases := map[string]struct {
+		errType cloudflare.ErrorType
+		newErr  func(*cloudflare.Error) error
+		status  int
+	}{
+		"authentication": {
+			errType: cloudflare.ErrorTypeAuthentication,
+			newErr:  func(e *cloudflare.Error) error { err := cloudflare.NewAuthenticationError(e); return &err },
+			status:  http.StatusForbidden,
+		},
+		"request": {
+			errType: cloudflare.ErrorTypeRequest,
+			newErr:  func(e *cloudflare.Error) error { err := cloudflare.NewRequestError(e); return &err },
+			status:  http.StatusBadRequest,
+		},
+		"rate limit": {
+			errType: cloudflare.ErrorTypeRateLimit,
+			newErr:  func(e *cloudflare.Error) error { err := cloudflare.NewRatelimitError(e); return &err },
+			status:  http.StatusTooManyRequests,
+		},
+		"service": {
+			newErr: func(e *cloudflare.Error) error { err := cloudflare.NewServiceError(e); return &err },
+			status: http.StatusBadGateway,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.newErr(&cloudflare.Error{
+				Type:       tc.errType,
+				ErrorCodes: []int{10000, 9109},
+				RayID:      "7d1c2a3b4c5d6e7f-LHR",
+			})
+
+			resp, err := cloudflareErrorResponse(req, fmt.Errorf("error creating service token: %w", err))
+			require.NoError(t, err)
+			require.Equal(t, tc.status, resp.Data[logical.HTTPStatusCode])
+
+			var body logical.HTTPResponse
+			require.NoError(t, json.Unmarshal([]byte(resp.Data[logical.HTTPRawBody].(string)), &body))
+			require.Equal(t, []string{"cloudflare error codes: 10000, 9109", "cloudflare ray id: 7d1c2a3b4c5d6e7f-LHR"}, body.Warnings)
+			require.Contains(t, body.Data["error"], "error creating service token")
+		})
+	}
+
+	t.Run("circuit open", func(t *testing.T) {
+		resp, err := cloudflareErrorResponse(req, fmt.Errorf("HTTP request failed: %w", errCircuitOpen))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.Data[logical.HTTPStatusCode])
+	})
+
+	t.Run("other", func(t *testing.T) {
+		original := errors.New("error retrieving role")
+		resp, err := cloudflareErrorResponse(req, original)
+		require.Nil(t, resp)
+		require.Equal(t, original, err)
+	})
+}
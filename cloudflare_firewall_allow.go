@@ -0,0 +1,272 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/google/uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareFirewallAllowType = "cloudflare_firewall_allow"
+
+	// IP Access Rules are only ever created to allow traffic.
+	accessRuleModeWhitelist = "whitelist"
+
+	// requesterIPv6PrefixLength is the network a requester's IPv6 address is widened to by
+	// default, as clients commonly rotate addresses within their /64.
+	requesterIPv6PrefixLength = 64
+)
+
+type cloudflareFirewallAllow struct {
+	EntryID string `json:"entry_id"`
+	IP      string `json:"ip"`
+	ListID  string `json:"list_id,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+func (allow *cloudflareFirewallAllow) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"entry_id": allow.EntryID,
+		"ip":       allow.IP,
+	}
+	if allow.ListID != "" {
+		respData["list_id"] = allow.ListID
+	} else {
+		respData["mode"] = allow.Mode
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareFirewallAllow() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareFirewallAllowType,
+		Fields: map[string]*framework.FieldSchema{
+			"entry_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare IP List Item or IP Access Rule ID",
+			},
+			"ip": {
+				Type:        framework.TypeString,
+				Description: "IP address or network that was added",
+			},
+			"list_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare IP List ID the entry was added to",
+			},
+			"mode": {
+				Type:        framework.TypeString,
+				Description: "Mode of the IP Access Rule",
+			},
+		},
+		Revoke: b.firewallAllowRevoke,
+		Renew:  b.firewallAllowRenew,
+	}
+}
+
+func (b *cloudflareBackend) firewallAllowRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entryIdRaw, ok := req.Secret.InternalData["entry_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing entry_id internal data")
+	}
+
+	accountId, _ := req.Secret.InternalData["account_id"].(string)
+	zoneId, _ := req.Secret.InternalData["zone_id"].(string)
+	listId, _ := req.Secret.InternalData["list_id"].(string)
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	// An entry already deleted, by hand or by an earlier revocation, needs no further cleanup.
+	var notFoundErr *cloudflare.NotFoundError
+	err = deleteFirewallAllow(ctx, client, accountId, zoneId, listId, entryIdRaw.(string))
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return nil, fmt.Errorf("error revoking firewall allow entry: %w", err)
+	}
+	return nil, nil
+}
+
+func (b *cloudflareBackend) firewallAllowRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp := &logical.Response{Secret: req.Secret}
+
+	return resp, nil
+}
+
+// requesterPrefix returns the network of the client making the request. IPv6 addresses are widened
+// to their network of ipv6PrefixLength bits.
+func requesterPrefix(req *logical.Request, ipv6PrefixLength int) (netip.Prefix, error) {
+	if req.Connection == nil || req.Connection.RemoteAddr == "" {
+		return netip.Prefix{}, fmt.Errorf("request has no remote address")
+	}
+
+	host := req.Connection.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("error parsing remote address: %w", err)
+	}
+	addr = addr.Unmap()
+
+	if addr.Is6() {
+		return addr.Prefix(ipv6PrefixLength)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// parsePrefix parses an IP address or CIDR block into its canonical network. IPv4-mapped IPv6
+// networks are returned as the IPv4 network they cover, so they are held to the IPv4 limits.
+func parsePrefix(value string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(value); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	if prefix.Addr().Is4In6() {
+		if prefix.Bits() < 96 {
+			return netip.Prefix{}, fmt.Errorf("ipv4-mapped network %q spans beyond the ipv4 space", value)
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// checkPrefixLength rejects a network broader than the role's minimum prefix length for its
+// address family.
+func checkPrefixLength(role *cloudflareRoleEntry, prefix netip.Prefix) error {
+	minPrefixLength := role.MinPrefixLengthIPv4
+	if prefix.Addr().Is6() {
+		minPrefixLength = role.MinPrefixLengthIPv6
+	}
+
+	if prefix.Bits() < minPrefixLength {
+		return fmt.Errorf("ip %q is broader than the /%d allowed by the role", prefix, minPrefixLength)
+	}
+	return nil
+}
+
+// prefixWithin reports whether prefix is entirely contained by one of the cidrs.
+func prefixWithin(prefix netip.Prefix, cidrs []string) bool {
+	for _, cidr := range cidrs {
+		allowed, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+		if allowed.Bits() <= prefix.Bits() && allowed.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixValue(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+func accessRuleTarget(prefix netip.Prefix) string {
+	switch {
+	case !prefix.IsSingleIP():
+		return "ip_range"
+	case prefix.Addr().Is6():
+		return "ip6"
+	default:
+		return "ip"
+	}
+}
+
+func createFirewallAllow(ctx context.Context, c cloudflareClient, role *cloudflareRoleEntry, prefix netip.Prefix, notes string) (*cloudflareFirewallAllow, error) {
+	if err := checkPrefixLength(role, prefix); err != nil {
+		return nil, err
+	}
+
+	value := prefixValue(prefix)
+
+	if role.ListID != "" {
+		// The list API returns every item in the list, so a unique comment identifies the new item.
+		comment := fmt.Sprintf("%s (%s)", notes, uuid.New().String())
+		items, err := c.CreateListItem(ctx, cloudflare.AccountIdentifier(role.AccountID), cloudflare.ListCreateItemParams{
+			ID: role.ListID,
+			Item: cloudflare.ListItemCreateRequest{
+				IP:      &value,
+				Comment: comment,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating ip list item: %w", err)
+		}
+
+		for _, item := range items {
+			if item.Comment == comment {
+				return &cloudflareFirewallAllow{
+					EntryID: item.ID,
+					IP:      value,
+					ListID:  role.ListID,
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("error creating ip list item: item not found in list %q", role.ListID)
+	}
+
+	rule := cloudflare.AccessRule{
+		Mode:  accessRuleModeWhitelist,
+		Notes: notes,
+		Configuration: cloudflare.AccessRuleConfiguration{
+			Target: accessRuleTarget(prefix),
+			Value:  value,
+		},
+	}
+
+	var response *cloudflare.AccessRuleResponse
+	var err error
+	if role.ZoneID != "" {
+		response, err = c.CreateZoneAccessRule(ctx, role.ZoneID, rule)
+	} else {
+		response, err = c.CreateAccountAccessRule(ctx, role.AccountID, rule)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating ip access rule: %w", err)
+	}
+
+	return &cloudflareFirewallAllow{
+		EntryID: response.Result.ID,
+		IP:      value,
+		Mode:    accessRuleModeWhitelist,
+	}, nil
+}
+
+func deleteFirewallAllow(ctx context.Context, c cloudflareClient, accountId string, zoneId string, listId string, entryId string) error {
+	var err error
+	switch {
+	case listId != "":
+		_, err = c.DeleteListItems(ctx, cloudflare.AccountIdentifier(accountId), cloudflare.ListDeleteItemsParams{
+			ID: listId,
+			Items: cloudflare.ListItemDeleteRequest{
+				Items: []cloudflare.ListItemDeleteItemRequest{{ID: entryId}},
+			},
+		})
+	case zoneId != "":
+		_, err = c.DeleteZoneAccessRule(ctx, zoneId, entryId)
+	default:
+		_, err = c.DeleteAccountAccessRule(ctx, accountId, entryId)
+	}
+
+	return err
+}
@@ -0,0 +1,149 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequesterPrefix(t *testing.T) {
+	for remoteAddr, expected := range map[string]string{
+		"203.0.113.10":            "203.0.113.10/32",
+		"203.0.113.10:51234":      "203.0.113.10/32",
+		"::ffff:203.0.113.10":     "203.0.113.10/32",
+		"2001:db8:1:2:3:4:5:6":    "2001:db8:1:2::/64",
+		"[2001:db8:1:2::1]:51234": "2001:db8:1:2::/64",
+	} {
+		prefix, err := requesterPrefix(&logical.Request{
+			Connection: &logical.Connection{RemoteAddr: remoteAddr},
+		}, requesterIPv6PrefixLength)
+		require.NoError(t, err)
+		require.Equal(t, expected, prefix.String())
+	}
+
+	prefix, err := requesterPrefix(&logical.Request{
+		Connection: &logical.Connection{RemoteAddr: "2001:db8:1:2:3:4:5:6"},
+	}, 128)
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8:1:2:3:4:5:6/128", prefix.String())
+
+	_, err = requesterPrefix(&logical.Request{}, requesterIPv6PrefixLength)
+	require.Error(t, err)
+}
+
+func TestPrefixWithin(t *testing.T) {
+	prefix, err := parsePrefix("10.1.2.3")
+	require.NoError(t, err)
+	require.True(t, prefixWithin(prefix, []string{"10.0.0.0/8"}))
+	require.False(t, prefixWithin(prefix, []string{"192.168.0.0/16"}))
+
+	prefix, err = parsePrefix("10.0.0.0/7")
+	require.NoError(t, err)
+	require.False(t, prefixWithin(prefix, []string{"10.0.0.0/8"}))
+}
+
+func TestParsePrefixMapped(t *testing.T) {
+	prefix, err := parsePrefix("::ffff:10.0.0.0/104")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.0/8", prefix.String())
+
+	_, err = parsePrefix("::ffff:0:0/90")
+	require.Error(t, err)
+}
+
+func TestFirewallAllowPrefixLength(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "office", map[string]interface{}{
+		"credential_type":        "firewall-allow",
+		"account_id":             accountId,
+		"allowed_cidrs":          "10.0.0.0/8,2001:db8::/32,::ffff:10.0.0.0/104",
+		"min_prefix_length_ipv4": 24,
+		"min_prefix_length_ipv6": 56,
+	})
+	require.NoError(t, err)
+
+	allow := func(ip string) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "firewall-allow/office",
+			Storage:   s,
+			Data:      map[string]interface{}{"ip": ip},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, ip := range []string{"10.1.0.0/16", "2001:db8::/48", "::ffff:10.1.0.0/112"} {
+		resp := allow(ip)
+		require.True(t, resp.IsError(), ip)
+	}
+	require.Empty(t, client.rules)
+
+	for ip, value := range map[string]string{
+		"10.1.2.0/24":         "10.1.2.0/24",
+		"2001:db8:1::/56":     "2001:db8:1::/56",
+		"::ffff:10.1.3.0/120": "10.1.3.0/24",
+	} {
+		resp := allow(ip)
+		require.False(t, resp.IsError(), resp.Error())
+		require.Equal(t, value, client.rules[resp.Secret.InternalData["entry_id"].(string)].Configuration.Value)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+	}
+	require.Empty(t, client.rules)
+}
+
+func TestCheckPrefixLength(t *testing.T) {
+	role := &cloudflareRoleEntry{MinPrefixLengthIPv4: 32, MinPrefixLengthIPv6: 64}
+
+	for value, allowed := range map[string]bool{
+		"203.0.113.10":    true,
+		"203.0.113.0/24":  false,
+		"2001:db8::/64":   true,
+		"2001:db8::1":     true,
+		"2001:db8::/48":   false,
+		"::ffff:10.0.0.1": true,
+	} {
+		prefix, err := parsePrefix(value)
+		require.NoError(t, err)
+		require.Equal(t, allowed, checkPrefixLength(role, prefix) == nil, value)
+	}
+}
+
+func TestFirewallAllowRequester(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "laptop", map[string]interface{}{
+		"credential_type":        "firewall-allow",
+		"account_id":             accountId,
+		"min_prefix_length_ipv6": 128,
+	})
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       "firewall-allow/laptop",
+		Storage:    s,
+		Connection: &logical.Connection{RemoteAddr: "2001:db8:1:2:3:4:5:6"},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp.Error())
+
+	entryId := resp.Secret.InternalData["entry_id"].(string)
+	require.Equal(t, "2001:db8:1:2:3:4:5:6", client.rules[entryId].Configuration.Value)
+	require.Equal(t, accessRuleModeWhitelist, client.rules[entryId].Mode)
+
+	_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+	require.NoError(t, err)
+	require.Empty(t, client.rules)
+
+	// Revoking an entry that is already gone succeeds.
+	_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+	require.NoError(t, err)
+}
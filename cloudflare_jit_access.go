@@ -0,0 +1,267 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/google/uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareAccessGrantType = "cloudflare_access_grant"
+
+	accessGrantStoragePrefix = "jit-access/"
+)
+
+type cloudflareAccessGrant struct {
+	GrantID       string `json:"grant_id"`
+	Email         string `json:"email"`
+	ApplicationID string `json:"application_id"`
+	PolicyID      string `json:"policy_id"`
+}
+
+// accessGrantEntry tracks the active grants for one email on one policy, so the
+// include rule is only removed once the last overlapping lease ends. Rules that were
+// already on the policy when Vault first granted the email are never removed.
+type accessGrantEntry struct {
+	GrantIDs        []string `json:"grant_ids"`
+	PreexistingRule bool     `json:"preexisting_rule,omitempty"`
+}
+
+func (grant *cloudflareAccessGrant) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"grant_id":       grant.GrantID,
+		"email":          grant.Email,
+		"application_id": grant.ApplicationID,
+		"policy_id":      grant.PolicyID,
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareAccessGrant() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareAccessGrantType,
+		Fields: map[string]*framework.FieldSchema{
+			"grant_id": {
+				Type:        framework.TypeString,
+				Description: "Vault identifier of the grant",
+			},
+			"email": {
+				Type:        framework.TypeString,
+				Description: "Email address granted access",
+			},
+			"application_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare Access Application ID",
+			},
+			"policy_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare Access Policy ID the email was added to",
+			},
+		},
+		Revoke: b.accessGrantRevoke,
+		Renew:  b.accessGrantRenew,
+	}
+}
+
+func (b *cloudflareBackend) accessGrantRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	grantIdRaw, ok := req.Secret.InternalData["grant_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing grant_id internal data")
+	}
+
+	emailRaw, ok := req.Secret.InternalData["email"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing email internal data")
+	}
+
+	accountIdRaw, ok := req.Secret.InternalData["account_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing account_id internal data")
+	}
+
+	applicationIdRaw, ok := req.Secret.InternalData["application_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing application_id internal data")
+	}
+
+	policyIdRaw, ok := req.Secret.InternalData["policy_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing policy_id internal data")
+	}
+
+	grant := &cloudflareAccessGrant{
+		GrantID:       grantIdRaw.(string),
+		Email:         emailRaw.(string),
+		ApplicationID: applicationIdRaw.(string),
+		PolicyID:      policyIdRaw.(string),
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	if err := b.revokeAccessGrant(ctx, req.Storage, client, accountIdRaw.(string), grant); err != nil {
+		return nil, fmt.Errorf("error revoking access grant: %w", err)
+	}
+	return nil, nil
+}
+
+func (b *cloudflareBackend) accessGrantRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp := &logical.Response{Secret: req.Secret}
+
+	return resp, nil
+}
+
+func (b *cloudflareBackend) createAccessGrant(ctx context.Context, s logical.Storage, c cloudflareClient, role *cloudflareRoleEntry, email string) (*cloudflareAccessGrant, error) {
+	b.accessPolicyLock.Lock()
+	defer b.accessPolicyLock.Unlock()
+
+	entry, err := getAccessGrantEntry(ctx, s, role.PolicyID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := updateAccessPolicyEmail(ctx, c, role.AccountID, role.ApplicationID, role.PolicyID, email, true)
+	if err != nil {
+		return nil, fmt.Errorf("error adding email to access policy: %w", err)
+	}
+
+	// If the rule was removed by hand while grants were active, Vault has now added it.
+	if len(entry.GrantIDs) == 0 || added {
+		entry.PreexistingRule = !added
+	}
+
+	grant := &cloudflareAccessGrant{
+		GrantID:       uuid.New().String(),
+		Email:         email,
+		ApplicationID: role.ApplicationID,
+		PolicyID:      role.PolicyID,
+	}
+
+	entry.GrantIDs = append(entry.GrantIDs, grant.GrantID)
+	if err := putAccessGrantEntry(ctx, s, role.PolicyID, email, entry); err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+func (b *cloudflareBackend) revokeAccessGrant(ctx context.Context, s logical.Storage, c cloudflareClient, accountId string, grant *cloudflareAccessGrant) error {
+	b.accessPolicyLock.Lock()
+	defer b.accessPolicyLock.Unlock()
+
+	entry, err := getAccessGrantEntry(ctx, s, grant.PolicyID, grant.Email)
+	if err != nil {
+		return err
+	}
+
+	entry.GrantIDs = strutil.StrListDelete(entry.GrantIDs, grant.GrantID)
+	if len(entry.GrantIDs) > 0 {
+		return putAccessGrantEntry(ctx, s, grant.PolicyID, grant.Email, entry)
+	}
+
+	if !entry.PreexistingRule {
+		if _, err := updateAccessPolicyEmail(ctx, c, accountId, grant.ApplicationID, grant.PolicyID, grant.Email, false); err != nil {
+			return err
+		}
+	}
+
+	return s.Delete(ctx, accessGrantStoragePath(grant.PolicyID, grant.Email))
+}
+
+// updateAccessPolicyEmail adds or removes an email include rule on an Access policy, and reports
+// whether the policy was changed.
+func updateAccessPolicyEmail(ctx context.Context, c cloudflareClient, accountId string, applicationId string, policyId string, email string, present bool) (bool, error) {
+	policy, err := c.AccessPolicy(ctx, accountId, applicationId, policyId)
+	if err != nil {
+		return false, err
+	}
+
+	var include []interface{}
+	found := false
+	for _, rule := range policy.Include {
+		if accessRuleEmail(rule) == strings.ToLower(email) {
+			found = true
+			if !present {
+				continue
+			}
+		}
+		include = append(include, rule)
+	}
+
+	if found == present {
+		return false, nil
+	}
+
+	if present {
+		rule := cloudflare.AccessGroupEmail{}
+		rule.Email.Email = email
+		include = append(include, rule)
+	}
+
+	policy.Include = include
+	if policy.Exclude == nil {
+		policy.Exclude = []interface{}{}
+	}
+	if policy.Require == nil {
+		policy.Require = []interface{}{}
+	}
+	if _, err := c.UpdateAccessPolicy(ctx, accountId, applicationId, policy); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// accessRuleEmail returns the lowercased address of an email include rule, or an empty string.
+func accessRuleEmail(rule interface{}) string {
+	ruleMap, ok := rule.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	emailRule, ok := ruleMap["email"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	email, _ := emailRule["email"].(string)
+	return strings.ToLower(email)
+}
+
+func accessGrantStoragePath(policyId string, email string) string {
+	return accessGrantStoragePrefix + policyId + "/" + strings.ToLower(email)
+}
+
+func getAccessGrantEntry(ctx context.Context, s logical.Storage, policyId string, email string) (*accessGrantEntry, error) {
+	entry, err := s.Get(ctx, accessGrantStoragePath(policyId, email))
+	if err != nil {
+		return nil, err
+	}
+
+	var grantEntry accessGrantEntry
+	if entry == nil {
+		return &grantEntry, nil
+	}
+
+	if err := entry.DecodeJSON(&grantEntry); err != nil {
+		return nil, err
+	}
+	return &grantEntry, nil
+}
+
+func putAccessGrantEntry(ctx context.Context, s logical.Storage, policyId string, email string, grantEntry *accessGrantEntry) error {
+	entry, err := logical.StorageEntryJSON(accessGrantStoragePath(policyId, email), grantEntry)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
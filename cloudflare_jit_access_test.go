@@ -0,0 +1,100 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessGrantLifecycle(t *testing.T) {
+	client := newFakeClient()
+	client.policies["testpolicyid"] = cloudflare.AccessPolicy{
+		ID: "testpolicyid",
+		Include: []interface{}{
+			map[string]interface{}{"email": map[string]interface{}{"email": "admin@example.com"}},
+		},
+	}
+
+	b, s := getTestBackendWithClient(t, client)
+	system := b.System().(*logical.StaticSystemView)
+
+	_, err := testServiceRoleCreate(t, b, s, "break-glass", map[string]interface{}{
+		"credential_type":       "jit-access",
+		"account_id":            accountId,
+		"application_id":        "testapplicationid",
+		"policy_id":             "testpolicyid",
+		"bound_entity_metadata": "team=sre",
+	})
+	require.NoError(t, err)
+
+	grant := func(email string) *logical.Response {
+		system.EntityVal = &logical.Entity{
+			ID:       "entity-" + email,
+			Name:     email,
+			Metadata: map[string]string{"email": email, "team": "sre"},
+		}
+
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "jit-access/break-glass",
+			Storage:   s,
+			EntityID:  "entity-" + email,
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+		return resp
+	}
+
+	policyEmails := func() []string {
+		var emails []string
+		for _, rule := range client.policies["testpolicyid"].Include {
+			emails = append(emails, accessRuleEmail(rule))
+		}
+		return emails
+	}
+
+	t.Run("Overlapping Grants", func(t *testing.T) {
+		first := grant("alice@example.com")
+		second := grant("alice@example.com")
+		require.Equal(t, []string{"admin@example.com", "alice@example.com"}, policyEmails())
+
+		_, err := testSecretRequest(t, b, s, logical.RenewOperation, first.Secret)
+		require.NoError(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, first.Secret)
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin@example.com", "alice@example.com"}, policyEmails())
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, second.Secret)
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin@example.com"}, policyEmails())
+	})
+
+	t.Run("Keep Rules Added By Hand", func(t *testing.T) {
+		resp := grant("admin@example.com")
+		require.Equal(t, []string{"admin@example.com"}, policyEmails())
+
+		_, err := testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin@example.com"}, policyEmails())
+	})
+
+	t.Run("Entity Not Bound", func(t *testing.T) {
+		system.EntityVal = &logical.Entity{
+			ID:       "entity-mallory",
+			Metadata: map[string]string{"email": "mallory@example.com", "team": "finance"},
+		}
+
+		_, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "jit-access/break-glass",
+			Storage:   s,
+			EntityID:  "entity-mallory",
+		})
+		require.ErrorIs(t, err, logical.ErrPermissionDenied)
+		require.Equal(t, []string{"admin@example.com"}, policyEmails())
+	})
+}
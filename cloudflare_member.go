@@ -0,0 +1,103 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareAccountMemberType = "cloudflare_account_member"
+
+	memberStatusPending  = "pending"
+	memberStatusAccepted = "accepted"
+)
+
+type cloudflareAccountMember struct {
+	MemberID string `json:"member_id"`
+	Email    string `json:"email"`
+	Status   string `json:"status"`
+}
+
+func (member *cloudflareAccountMember) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"member_id": member.MemberID,
+		"email":     member.Email,
+		"status":    member.Status,
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareAccountMember() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareAccountMemberType,
+		Fields: map[string]*framework.FieldSchema{
+			"member_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare Account Member ID",
+			},
+			"email": {
+				Type:        framework.TypeString,
+				Description: "Email address of the member",
+			},
+			"status": {
+				Type:        framework.TypeString,
+				Description: "Status of the membership",
+			},
+		},
+		Revoke: b.accountMemberRevoke,
+		Renew:  b.accountMemberRenew,
+	}
+}
+
+func (b *cloudflareBackend) accountMemberRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	memberIdRaw, ok := req.Secret.InternalData["member_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing member_id internal data")
+	}
+
+	accountIdRaw, ok := req.Secret.InternalData["account_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing account_id internal data")
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	// A member already removed, by hand or by an earlier revocation, needs no further cleanup.
+	var notFoundErr *cloudflare.NotFoundError
+	err = client.DeleteAccountMember(ctx, accountIdRaw.(string), memberIdRaw.(string))
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return nil, fmt.Errorf("error revoking account member: %w", err)
+	}
+	return nil, nil
+}
+
+func (b *cloudflareBackend) accountMemberRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp := &logical.Response{Secret: req.Secret}
+
+	return resp, nil
+}
+
+func createAccountMember(ctx context.Context, c cloudflareClient, role *cloudflareRoleEntry, email string) (*cloudflareAccountMember, error) {
+	response, err := c.CreateAccountMember(ctx, cloudflare.AccountIdentifier(role.AccountID), cloudflare.CreateAccountMemberParams{
+		EmailAddress: email,
+		Roles:        role.AccountRoles,
+		Status:       role.MemberStatus,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating account member: %w", err)
+	}
+
+	return &cloudflareAccountMember{
+		MemberID: response.ID,
+		Email:    email,
+		Status:   response.Status,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountMemberLifecycle(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+	system := b.System().(*logical.StaticSystemView)
+
+	_, err := testServiceRoleCreate(t, b, s, "contractors", map[string]interface{}{
+		"credential_type": "member",
+		"account_id":      accountId,
+		"account_roles":   "testaccountroleid",
+		"allowed_emails":  "*@example.com",
+	})
+	require.NoError(t, err)
+
+	addMember := func(entityId string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "member/contractors",
+			Data:      data,
+			Storage:   s,
+			EntityID:  entityId,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("Issue, Renew And Revoke", func(t *testing.T) {
+		system.EntityVal = &logical.Entity{
+			ID:       "entity-alice",
+			Metadata: map[string]string{"email": "Alice@Example.com"},
+		}
+
+		resp := addMember("entity-alice", nil)
+		require.False(t, resp.IsError(), resp.Error())
+		require.NotNil(t, resp.Secret)
+
+		memberId := resp.Data["member_id"].(string)
+		require.Equal(t, "alice@example.com", client.members[memberId].User.Email)
+		require.Equal(t, []cloudflare.AccountRole{{ID: "testaccountroleid"}}, client.members[memberId].Roles)
+
+		_, err := testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.NoError(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, client.members, memberId)
+
+		// Revoking a member that is already gone succeeds.
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+	})
+
+	t.Run("Requested Emails Must Be Allowed", func(t *testing.T) {
+		resp := addMember("", map[string]interface{}{"email": "mallory@example.org"})
+		require.True(t, resp.IsError())
+
+		resp = addMember("", map[string]interface{}{"email": "bob@example.com"})
+		require.False(t, resp.IsError(), resp.Error())
+
+		_, err := testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.Empty(t, client.members)
+	})
+}
@@ -0,0 +1,235 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	cloudflareOriginCACertificateType = "cloudflare_origin_ca_certificate"
+
+	originCADefaultValidityDays = 90
+)
+
+// originCAValidityDays are the certificate lifetimes accepted by the Origin CA API.
+var originCAValidityDays = []int{7, 30, 90, 365, 730, 1095, 5475}
+
+// originCARSARoot is the Cloudflare Origin CA RSA root certificate, valid until August 2029.
+const originCARSARoot = `-----BEGIN CERTIFICATE-----
+MIIEADCCAuigAwIBAgIID+rOSdTGfGcwDQYJKoZIhvcNAQELBQAwgYsxCzAJBgNV
+BAYTAlVTMRkwFwYDVQQKExBDbG91ZEZsYXJlLCBJbmMuMTQwMgYDVQQLEytDbG91
+ZEZsYXJlIE9yaWdpbiBTU0wgQ2VydGlmaWNhdGUgQXV0aG9yaXR5MRYwFAYDVQQH
+Ew1TYW4gRnJhbmNpc2NvMRMwEQYDVQQIEwpDYWxpZm9ybmlhMB4XDTE5MDgyMzIx
+MDgwMFoXDTI5MDgxNTE3MDAwMFowgYsxCzAJBgNVBAYTAlVTMRkwFwYDVQQKExBD
+bG91ZEZsYXJlLCBJbmMuMTQwMgYDVQQLEytDbG91ZEZsYXJlIE9yaWdpbiBTU0wg
+Q2VydGlmaWNhdGUgQXV0aG9yaXR5MRYwFAYDVQQHEw1TYW4gRnJhbmNpc2NvMRMw
+EQYDVQQIEwpDYWxpZm9ybmlhMIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKC
+AQEAwEiVZ/UoQpHmFsHvk5isBxRehukP8DG9JhFev3WZtG76WoTthvLJFRKFCHXm
+V6Z5/66Z4S09mgsUuFwvJzMnE6Ej6yIsYNCb9r9QORa8BdhrkNn6kdTly3mdnykb
+OomnwbUfLlExVgNdlP0XoRoeMwbQ4598foiHblO2B/LKuNfJzAMfS7oZe34b+vLB
+yrP/1bgCSLdc1AxQc1AC0EsQQhgcyTJNgnG4va1c7ogPlwKyhbDyZ4e59N5lbYPJ
+SmXI/cAe3jXj1FBLJZkwnoDKe0v13xeF+nF32smSH0qB7aJX2tBMW4TWtFPmzs5I
+lwrFSySWAdwYdgxw180yKU0dvwIDAQABo2YwZDAOBgNVHQ8BAf8EBAMCAQYwEgYD
+VR0TAQH/BAgwBgEB/wIBAjAdBgNVHQ4EFgQUJOhTV118NECHqeuU27rhFnj8KaQw
+HwYDVR0jBBgwFoAUJOhTV118NECHqeuU27rhFnj8KaQwDQYJKoZIhvcNAQELBQAD
+ggEBAHwOf9Ur1l0Ar5vFE6PNrZWrDfQIMyEfdgSKofCdTckbqXNTiXdgbHs+TWoQ
+wAB0pfJDAHJDXOTCWRyTeXOseeOi5Btj5CnEuw3P0oXqdqevM1/+uWp0CM35zgZ8
+VD4aITxity0djzE6Qnx3Syzz+ZkoBgTnNum7d9A66/V636x4vTeqbZFBr9erJzgz
+hhurjcoacvRNhnjtDRM0dPeiCJ50CP3wEYuvUzDHUaowOsnLCjQIkWbR7Ni6KEIk
+MOz2U0OBSif3FTkhCgZWQKOOLo1P42jHC3ssUZAtVNXrCk3fw9/E15k8NPkBazZ6
+0iykLhH1trywrKRMVw67F44IE8Y=
+-----END CERTIFICATE-----`
+
+// originCARoots are the Cloudflare Origin CA root certificates by key type. Origin CA certificates
+// are issued directly by the root, so it makes up the whole chain. The ECC root is not embedded
+// yet, so certificates for ECDSA keys are returned without a chain.
+var originCARoots = map[string]string{
+	keyTypeRSA: originCARSARoot,
+}
+
+type cloudflareOriginCACertificate struct {
+	CertificateID  string    `json:"certificate_id"`
+	Certificate    string    `json:"certificate"`
+	CAChain        []string  `json:"ca_chain,omitempty"`
+	Hostnames      []string  `json:"hostnames"`
+	Expiration     time.Time `json:"expiration"`
+	PrivateKey     string    `json:"private_key,omitempty"`
+	PrivateKeyType string    `json:"private_key_type,omitempty"`
+}
+
+func (cert *cloudflareOriginCACertificate) toResponseData() map[string]interface{} {
+	respData := map[string]interface{}{
+		"certificate_id": cert.CertificateID,
+		"certificate":    cert.Certificate,
+		"hostnames":      cert.Hostnames,
+		"expiration":     cert.Expiration.Unix(),
+	}
+	if len(cert.CAChain) > 0 {
+		respData["ca_chain"] = cert.CAChain
+	}
+	if cert.PrivateKey != "" {
+		respData["private_key"] = cert.PrivateKey
+		respData["private_key_type"] = cert.PrivateKeyType
+	}
+	return respData
+}
+
+func (b *cloudflareBackend) cloudflareOriginCACertificate() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareOriginCACertificateType,
+		Fields: map[string]*framework.FieldSchema{
+			"certificate_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare Origin CA Certificate ID",
+			},
+			"certificate": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare Origin CA Certificate in PEM format",
+			},
+			"ca_chain": {
+				Type:        framework.TypeStringSlice,
+				Description: "Cloudflare Origin CA root certificate for the role's key type in PEM format",
+			},
+			"hostnames": {
+				Type:        framework.TypeStringSlice,
+				Description: "Hostnames the certificate is valid for",
+			},
+			"expiration": {
+				Type:        framework.TypeInt64,
+				Description: "Expiration of the certificate as a unix timestamp",
+			},
+			"private_key": {
+				Type:        framework.TypeString,
+				Description: "Private key in PEM format, only returned when generated by Vault",
+			},
+			"private_key_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the generated private key",
+			},
+		},
+		Revoke: b.originCACertificateRevoke,
+		Renew:  b.originCACertificateRenew,
+	}
+}
+
+func (b *cloudflareBackend) originCACertificateRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	certificateIdRaw, ok := req.Secret.InternalData["certificate_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing certificate_id internal data")
+	}
+
+	certificateId := certificateIdRaw.(string)
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	if _, err := client.RevokeOriginCACertificate(ctx, certificateId); err != nil {
+		return nil, fmt.Errorf("error revoking origin ca certificate: %w", err)
+	}
+	return nil, nil
+}
+
+func (b *cloudflareBackend) originCACertificateRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	expirationRaw, ok := req.Secret.InternalData["expiration"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing expiration internal data")
+	}
+
+	expiration, err := time.Parse(time.RFC3339, expirationRaw.(string))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificate expiration: %w", err)
+	}
+
+	remaining := time.Until(expiration)
+	if remaining <= 0 {
+		return nil, fmt.Errorf("origin ca certificate has expired")
+	}
+
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.MaxTTL = remaining
+	if resp.Secret.TTL > remaining {
+		resp.Secret.TTL = remaining
+	}
+
+	return resp, nil
+}
+
+func originCARequestType(keyType string) (string, error) {
+	switch keyType {
+	case keyTypeRSA:
+		return "origin-rsa", nil
+	case keyTypeECDSA:
+		return "origin-ecc", nil
+	default:
+		return "", fmt.Errorf("invalid key_type in cloudflare role: %q", keyType)
+	}
+}
+
+func validOriginCAValidity(days int) bool {
+	for _, v := range originCAValidityDays {
+		if v == days {
+			return true
+		}
+	}
+	return false
+}
+
+func createOriginCACertificate(ctx context.Context, c cloudflareClient, hostnames []string, keyType string, csr string, validityDays int) (*cloudflareOriginCACertificate, error) {
+	requestType, err := originCARequestType(keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	// CreateOriginCACertificate in cloudflare-go never decodes its response, so the
+	// request is made directly against the certificates endpoint.
+	raw, err := c.Raw(ctx, http.MethodPost, "/certificates", cloudflare.CreateOriginCertificateParams{
+		Hostnames:       hostnames,
+		RequestType:     requestType,
+		RequestValidity: validityDays,
+		CSR:             csr,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating origin ca certificate: %w", err)
+	}
+
+	var response cloudflare.OriginCACertificate
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, fmt.Errorf("error decoding origin ca certificate: %w", err)
+	}
+
+	return &cloudflareOriginCACertificate{
+		CertificateID: response.ID,
+		Certificate:   response.Certificate,
+		Hostnames:     response.Hostnames,
+		Expiration:    response.ExpiresOn,
+	}, nil
+}
+
+// hostnameAllowed reports whether a hostname matches one of a role's allowed hostnames or targets.
+// Globs only match within a single label, so "*.example.com" allows "www.example.com" but not
+// "a.b.example.com", as a wildcard certificate would.
+func hostnameAllowed(allowedHostnames []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+
+	// With as many labels as the hostname, no glob can span a dot.
+	var candidates []string
+	for _, allowed := range allowedHostnames {
+		allowed = strings.ToLower(allowed)
+		if strings.Count(allowed, ".") == strings.Count(hostname, ".") {
+			candidates = append(candidates, allowed)
+		}
+	}
+
+	return strutil.StrListContainsGlob(candidates, hostname)
+}
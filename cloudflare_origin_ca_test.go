@@ -0,0 +1,115 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func testOriginCAWrite(t *testing.T, b *cloudflareBackend, s logical.Storage, name string, data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "origin-ca/" + name,
+		Data:      data,
+		Storage:   s,
+	})
+}
+
+func TestOriginCACertificateLifecycle(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "origin", map[string]interface{}{
+		"credential_type":   "origin-ca",
+		"allowed_hostnames": "example.com,*.example.com",
+		"key_type":          "ecdsa",
+	})
+	require.NoError(t, err)
+
+	t.Run("Issue, Renew And Revoke", func(t *testing.T) {
+		resp, err := testOriginCAWrite(t, b, s, "origin", nil)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+		require.NotNil(t, resp.Secret)
+
+		certificateId := resp.Data["certificate_id"].(string)
+		require.Contains(t, client.originCA, certificateId)
+		require.Equal(t, []string{"example.com", "*.example.com"}, client.originCA[certificateId].Hostnames)
+		require.Equal(t, "origin-ecc", client.originCA[certificateId].RequestType)
+		require.Equal(t, originCADefaultValidityDays, client.originCA[certificateId].RequestValidity)
+		require.NotEmpty(t, resp.Data["private_key"])
+		require.NotContains(t, resp.Data, "ca_chain")
+		require.Len(t, resp.Warnings, 1)
+
+		renewed, err := testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.NoError(t, err)
+		require.LessOrEqual(t, renewed.Secret.MaxTTL, resp.Secret.MaxTTL)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, client.originCA, certificateId)
+	})
+
+	t.Run("Wildcards Match A Single Label", func(t *testing.T) {
+		resp, err := testOriginCAWrite(t, b, s, "origin", map[string]interface{}{
+			"hostnames": "www.example.com",
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+
+		resp, err = testOriginCAWrite(t, b, s, "origin", map[string]interface{}{
+			"hostnames": "a.b.example.com",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func TestOriginCACertificateChain(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "origin", map[string]interface{}{
+		"credential_type":   "origin-ca",
+		"allowed_hostnames": "example.com",
+		"key_type":          "rsa",
+	})
+	require.NoError(t, err)
+
+	resp, err := testOriginCAWrite(t, b, s, "origin", nil)
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp.Error())
+	require.Empty(t, resp.Warnings)
+
+	chain := resp.Data["ca_chain"].([]string)
+	require.Len(t, chain, 1)
+
+	block, _ := pem.Decode([]byte(chain[0]))
+	require.NotNil(t, block)
+	root, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.True(t, root.IsCA)
+	require.Equal(t, x509.RSA, root.PublicKeyAlgorithm)
+	require.Equal(t, []string{"CloudFlare Origin SSL Certificate Authority"}, root.Subject.OrganizationalUnit)
+	require.NoError(t, root.CheckSignatureFrom(root))
+}
+
+func TestHostnameAllowed(t *testing.T) {
+	allowed := []string{"example.com", "*.example.com", "www.example.*", "_acme-challenge.*.example.org"}
+
+	require.True(t, hostnameAllowed(allowed, "example.com"))
+	require.True(t, hostnameAllowed(allowed, "WWW.example.com"))
+	require.True(t, hostnameAllowed(allowed, "*.example.com"))
+	require.True(t, hostnameAllowed(allowed, "www.example.net"))
+	require.False(t, hostnameAllowed(allowed, "a.b.example.com"))
+	require.False(t, hostnameAllowed(allowed, "*.b.example.com"))
+	require.False(t, hostnameAllowed(allowed, "www.example.co.uk"))
+	require.False(t, hostnameAllowed(allowed, "example.org"))
+	require.True(t, hostnameAllowed(allowed, "_acme-challenge.www.example.org"))
+	require.False(t, hostnameAllowed(allowed, "_acme-challenge.a.b.example.org"))
+}
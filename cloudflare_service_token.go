@@ -5,13 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cloudflare/cloudflare-go"
-	"github.com/google/uuid"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
 const (
-	cloudflareServiceTokenType = "cloudflare_service_token"
+	cloudflareServiceTokenType      = "cloudflare_service_token"
+	cloudflareServiceTokenBatchType = "cloudflare_service_token_batch"
 )
 
 type cloudflareServiceToken struct {
@@ -57,19 +57,71 @@ func (b *cloudflareBackend) cloudflareServiceToken() *framework.Secret {
 	}
 }
 
-func (b *cloudflareBackend) tokenRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+// cloudflareServiceTokenBatch is a single lease covering every service token issued by a batch request,
+// as a Vault response can only carry one lease. The tokens are renewed and revoked together.
+func (b *cloudflareBackend) cloudflareServiceTokenBatch() *framework.Secret {
+	return &framework.Secret{
+		Type: cloudflareServiceTokenBatchType,
+		Fields: map[string]*framework.FieldSchema{
+			"tokens": {
+				Type:        framework.TypeSlice,
+				Description: "Cloudflare Access Service Tokens, each with token_id, token_name, client_id and client_secret",
+			},
+		},
+		Revoke: b.tokenBatchRevoke,
+		Renew:  b.tokenBatchRenew,
+	}
+}
+
+func (b *cloudflareBackend) tokenBatchRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenIdsRaw, ok := req.Secret.InternalData["token_ids"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing token_ids internal data")
+	}
+
+	accountId, err := b.leaseAccountID(ctx, req.Storage, req.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	// Tokens deleted by an earlier, partially failed revocation are already gone.
+	var notFoundErr *cloudflare.NotFoundError
+	for _, tokenId := range internalDataStrings(tokenIdsRaw) {
+		if err := deleteToken(ctx, client, accountId, tokenId); err != nil && !errors.As(err, &notFoundErr) {
+			return nil, fmt.Errorf("error revoking service token %q: %w", tokenId, err)
+		}
+	}
+
+	if roleName, activeId, ok := leaseActiveCredentials(req.Secret); ok {
+		if err := b.releaseActiveCredentials(ctx, req.Storage, roleName, activeId, leaseCredentialCount(req.Secret)); err != nil {
+			return nil, fmt.Errorf("error releasing max_active reservation: %w", err)
+		}
+	}
+
+	if err := forgetIssuedCredentials(ctx, req.Storage, leaseTokenIds(req.Secret)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *cloudflareBackend) tokenBatchRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	roleRaw, ok := req.Secret.InternalData["role"]
 	if !ok {
 		return nil, fmt.Errorf("secret is missing role internal data")
 	}
 
-	tokenIdRaw, ok := req.Secret.InternalData["token_id"]
+	tokenIdsRaw, ok := req.Secret.InternalData["token_ids"]
 	if !ok {
-		return nil, fmt.Errorf("secret is missing token_id internal data")
+		return nil, fmt.Errorf("secret is missing token_ids internal data")
 	}
 
-	role := roleRaw.(string)
-	roleEntry, err := b.getRole(ctx, req.Storage, role)
+	roleEntry, err := b.getRole(ctx, req.Storage, roleRaw.(string))
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving role: %w", err)
 	}
@@ -78,16 +130,76 @@ func (b *cloudflareBackend) tokenRevoke(ctx context.Context, req *logical.Reques
 		return nil, errors.New("error retrieving role: role is nil")
 	}
 
-	tokenId, ok := tokenIdRaw.(string)
-
 	client, err := b.getClient(ctx, req.Storage)
 	if err != nil {
 		return nil, fmt.Errorf("error getting client: %w", err)
 	}
 
-	if err := deleteToken(ctx, client, tokenId, roleEntry); err != nil {
+	for _, tokenId := range internalDataStrings(tokenIdsRaw) {
+		if err := renewToken(ctx, client, tokenId, roleEntry); err != nil {
+			return nil, renewTokenError(tokenId, err)
+		}
+	}
+
+	if roleName, activeId, ok := leaseActiveCredentials(req.Secret); ok {
+		if err := b.renewActiveCredentials(ctx, req.Storage, roleName, activeId, req.Secret); err != nil {
+			return nil, fmt.Errorf("error renewing max_active reservation: %w", err)
+		}
+	}
+
+	resp := &logical.Response{Secret: req.Secret}
+
+	return resp, nil
+}
+
+func (b *cloudflareBackend) tokenRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenIdRaw, ok := req.Secret.InternalData["token_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing token_id internal data")
+	}
+
+	accountId, err := b.leaseAccountID(ctx, req.Storage, req.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId := tokenIdRaw.(string)
+
+	workerSecrets := internalDataStrings(req.Secret.InternalData["worker_secrets"])
+	if err := b.releaseWorkerSecrets(ctx, req.Storage, accountId, workerSecrets, tokenId); err != nil {
 		return nil, fmt.Errorf("error revoking service token: %w", err)
 	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+
+	// A token deleted for being idle is already gone, and the idle sweep has forgotten its record.
+	if err := deleteToken(ctx, client, accountId, tokenId); err != nil {
+		var notFoundErr *cloudflare.NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return nil, fmt.Errorf("error revoking service token: %w", err)
+		}
+		issued, issuedErr := getIssuedCredential(ctx, req.Storage, tokenId)
+		if issuedErr != nil {
+			return nil, issuedErr
+		}
+		if issued != nil {
+			return nil, fmt.Errorf("error revoking service token: %w", err)
+		}
+	}
+
+	if roleName, activeId, ok := leaseActiveCredentials(req.Secret); ok {
+		if err := b.releaseActiveCredentials(ctx, req.Storage, roleName, activeId, leaseCredentialCount(req.Secret)); err != nil {
+			return nil, fmt.Errorf("error releasing max_active reservation: %w", err)
+		}
+	}
+
+	if err := forgetIssuedCredentials(ctx, req.Storage, leaseTokenIds(req.Secret)); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
@@ -120,7 +232,13 @@ func (b *cloudflareBackend) tokenRenew(ctx context.Context, req *logical.Request
 	}
 
 	if err := renewToken(ctx, client, tokenId, roleEntry); err != nil {
-		return nil, fmt.Errorf("error renewing service token: %w", err)
+		return nil, renewTokenError(tokenId, err)
+	}
+
+	if roleName, activeId, ok := leaseActiveCredentials(req.Secret); ok {
+		if err := b.renewActiveCredentials(ctx, req.Storage, roleName, activeId, req.Secret); err != nil {
+			return nil, fmt.Errorf("error renewing max_active reservation: %w", err)
+		}
 	}
 
 	resp := &logical.Response{Secret: req.Secret}
@@ -128,10 +246,43 @@ func (b *cloudflareBackend) tokenRenew(ctx context.Context, req *logical.Request
 	return resp, nil
 }
 
-func createToken(ctx context.Context, c *cloudflareClient, role *cloudflareRoleEntry) (*cloudflareServiceToken, error) {
+// leaseAccountID returns the account the service tokens of a lease were created in, so they can be
+// revoked after their role was changed or deleted. Leases issued before the account was recorded
+// fall back to their role's account.
+func (b *cloudflareBackend) leaseAccountID(ctx context.Context, s logical.Storage, secret *logical.Secret) (string, error) {
+	if accountId, ok := secret.InternalData["account_id"].(string); ok && accountId != "" {
+		return accountId, nil
+	}
+
+	roleName, ok := secret.InternalData["role"].(string)
+	if !ok {
+		return "", fmt.Errorf("secret is missing account_id and role internal data")
+	}
+
+	roleEntry, err := b.getRole(ctx, s, roleName)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return "", fmt.Errorf("secret is missing account_id internal data and role %q no longer exists", roleName)
+	}
+
+	return roleEntry.AccountID, nil
+}
+
+// renewTokenError describes a failed renewal. A token that no longer exists was most likely deleted
+// for exceeding its role's max_idle.
+func renewTokenError(tokenId string, err error) error {
+	var notFoundErr *cloudflare.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return fmt.Errorf("service token %q no longer exists, it was likely deleted after exceeding the role's max_idle: %w", tokenId, err)
+	}
+	return fmt.Errorf("error renewing service token %q: %w", tokenId, err)
+}
 
-	suffix := uuid.New().String()
-	response, err := c.CreateAccessServiceToken(ctx, role.AccountID, "vault-account-"+suffix)
+func createToken(ctx context.Context, c cloudflareClient, name string, role *cloudflareRoleEntry) (*cloudflareServiceToken, error) {
+	response, err := c.CreateAccessServiceToken(ctx, role.AccountID, name)
 	if err != nil {
 		return nil, fmt.Errorf("error creating account service token: %w", err)
 	}
@@ -143,7 +294,7 @@ func createToken(ctx context.Context, c *cloudflareClient, role *cloudflareRoleE
 	}, nil
 }
 
-func renewToken(ctx context.Context, c *cloudflareClient, tokenId string, role *cloudflareRoleEntry) error {
+func renewToken(ctx context.Context, c cloudflareClient, tokenId string, role *cloudflareRoleEntry) error {
 	resourceContainer := cloudflare.AccountIdentifier(role.AccountID)
 	_, err := c.RefreshAccessServiceToken(ctx, resourceContainer, tokenId)
 
@@ -154,8 +305,8 @@ func renewToken(ctx context.Context, c *cloudflareClient, tokenId string, role *
 	return nil
 }
 
-func deleteToken(ctx context.Context, c *cloudflareClient, tokenId string, role *cloudflareRoleEntry) error {
-	_, err := c.DeleteAccessServiceToken(ctx, role.AccountID, tokenId)
+func deleteToken(ctx context.Context, c cloudflareClient, accountId string, tokenId string) error {
+	_, err := c.DeleteAccessServiceToken(ctx, accountId, tokenId)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,142 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func testServiceTokenRead(t *testing.T, b *cloudflareBackend, s logical.Storage, name string, d map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "service-token/" + name,
+		Data:      d,
+		Storage:   s,
+	})
+}
+
+func testSecretRequest(t *testing.T, b *cloudflareBackend, s logical.Storage, op logical.Operation, secret *logical.Secret) (*logical.Response, error) {
+	t.Helper()
+	secret.IssueTime = time.Now()
+	secret.Increment = time.Hour
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Secret:    secret,
+		Storage:   s,
+	})
+}
+
+func TestServiceTokenLifecycle(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+		"max_batch":       5,
+	})
+	require.NoError(t, err)
+
+	t.Run("Issue, Renew And Revoke", func(t *testing.T) {
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Secret)
+
+		tokenId := resp.Data["token_id"].(string)
+		require.Contains(t, client.tokens, tokenId)
+
+		_, err = testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.NoError(t, err)
+		require.Equal(t, 1, client.refreshes[tokenId])
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, client.tokens, tokenId)
+	})
+
+	t.Run("Revoke Fails When Cloudflare Does", func(t *testing.T) {
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+
+		serviceErr := cloudflare.NewServiceError(&cloudflare.Error{StatusCode: 503})
+		client.err = &serviceErr
+		defer func() { client.err = nil }()
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.Error(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.Error(t, err)
+	})
+
+	t.Run("Upstream Failure", func(t *testing.T) {
+		client.failCreateAfter = client.nextId
+		defer func() { client.failCreateAfter = 0 }()
+
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.Data[logical.HTTPStatusCode])
+	})
+
+	t.Run("Batch Rolls Back On Failure", func(t *testing.T) {
+		before := len(client.tokens)
+		client.failCreateAfter = client.nextId + 2
+		defer func() { client.failCreateAfter = 0 }()
+
+		resp, err := testServiceTokenRead(t, b, s, "ci", map[string]interface{}{"count": 4})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.Data[logical.HTTPStatusCode])
+		require.Len(t, client.tokens, before)
+	})
+
+	t.Run("Batch Revoke Tolerates Deleted Tokens", func(t *testing.T) {
+		resp, err := testServiceTokenRead(t, b, s, "ci", map[string]interface{}{"count": 3})
+		require.NoError(t, err)
+		require.Len(t, resp.Data["tokens"], 3)
+		require.Len(t, resp.Warnings, 1)
+		require.Contains(t, resp.Warnings[0], "share a single lease")
+
+		tokenIds := resp.Secret.InternalData["token_ids"].([]string)
+		_, err = client.DeleteAccessServiceToken(context.Background(), accountId, tokenIds[0])
+		require.NoError(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		for _, tokenId := range tokenIds {
+			require.NotContains(t, client.tokens, tokenId)
+		}
+	})
+	t.Run("Revoke After The Role Is Deleted", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "deploy", map[string]interface{}{
+			"credential_type": "service",
+			"account_id":      accountId,
+			"worker_secrets":  "api-worker:ACCESS_CLIENT_ID:client_id",
+		})
+		require.NoError(t, err)
+
+		resp, err := testServiceTokenRead(t, b, s, "deploy", nil)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+
+		tokenId := resp.Data["token_id"].(string)
+		require.Contains(t, client.workerSecrets, "api-worker/ACCESS_CLIENT_ID")
+
+		_, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.DeleteOperation,
+			Path:      "role/deploy",
+			Storage:   s,
+		})
+		require.NoError(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, client.tokens, tokenId)
+		require.NotContains(t, client.workerSecrets, "api-worker/ACCESS_CLIENT_ID")
+	})
+}
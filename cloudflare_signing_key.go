@@ -0,0 +1,179 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	signingKeyTypeStream = "stream"
+	signingKeyTypeImages = "images"
+)
+
+// streamSigningKey is the result of the Stream signing keys endpoint, which cloudflare-go does not yet wrap.
+type streamSigningKey struct {
+	ID  string `json:"id"`
+	PEM string `json:"pem"`
+}
+
+// imagesSigningKeys is the result of the Images signing keys endpoint, which cloudflare-go does not yet wrap.
+type imagesSigningKeys struct {
+	Keys []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"keys"`
+}
+
+// streamTokenClaims are the claims of a Stream signed URL token.
+type streamTokenClaims struct {
+	Subject      string        `json:"sub"`
+	KeyID        string        `json:"kid"`
+	Expiry       int64         `json:"exp"`
+	NotBefore    int64         `json:"nbf,omitempty"`
+	Downloadable bool          `json:"downloadable,omitempty"`
+	AccessRules  []interface{} `json:"accessRules,omitempty"`
+}
+
+func createStreamSigningKey(ctx context.Context, c cloudflareClient, accountId string) (string, string, error) {
+	raw, err := c.Raw(ctx, http.MethodPost, fmt.Sprintf("/accounts/%s/stream/keys", accountId), nil, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating stream signing key: %w", err)
+	}
+
+	var key streamSigningKey
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return "", "", fmt.Errorf("error decoding stream signing key: %w", err)
+	}
+
+	privateKey, err := base64.StdEncoding.DecodeString(key.PEM)
+	if err != nil {
+		return "", "", fmt.Errorf("error decoding stream signing key: %w", err)
+	}
+
+	return key.ID, string(privateKey), nil
+}
+
+func deleteStreamSigningKey(ctx context.Context, c cloudflareClient, accountId string, keyId string) error {
+	_, err := c.Raw(ctx, http.MethodDelete, fmt.Sprintf("/accounts/%s/stream/keys/%s", accountId, keyId), nil, nil)
+	return err
+}
+
+func createImagesSigningKey(ctx context.Context, c cloudflareClient, accountId string, name string) (string, error) {
+	raw, err := c.Raw(ctx, http.MethodPut, fmt.Sprintf("/accounts/%s/images/v1/keys/%s", accountId, url.PathEscape(name)), nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating images signing key: %w", err)
+	}
+
+	var keys imagesSigningKeys
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return "", fmt.Errorf("error decoding images signing key: %w", err)
+	}
+
+	for _, key := range keys.Keys {
+		if key.Name == name {
+			return key.Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("error creating images signing key: key %q not returned", name)
+}
+
+func deleteImagesSigningKey(ctx context.Context, c cloudflareClient, accountId string, name string) error {
+	_, err := c.Raw(ctx, http.MethodDelete, fmt.Sprintf("/accounts/%s/images/v1/keys/%s", accountId, url.PathEscape(name)), nil, nil)
+	return err
+}
+
+// parseRSAPrivateKey parses a PEM encoded RSA private key in PKCS#1 or PKCS#8 form.
+// Keys returned by the Stream API are additionally base64 encoded, which is accepted too.
+func parseRSAPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	privateKey = strings.TrimSpace(privateKey)
+	if !strings.HasPrefix(privateKey, "-----") {
+		decoded, err := base64.StdEncoding.DecodeString(privateKey)
+		if err != nil {
+			return nil, errors.New("private key is not PEM encoded")
+		}
+		privateKey = string(decoded)
+	}
+
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// signStreamToken mints an RS256 signed URL token for a Stream video.
+func signStreamToken(privateKey string, keyId string, claims streamTokenClaims) (string, error) {
+	key, err := parseRSAPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyId),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error creating signer: %w", err)
+	}
+
+	claims.KeyID = keyId
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+// signImagesURL appends an expiry and HMAC-SHA256 signature to an Images delivery URL.
+func signImagesURL(key string, rawURL string, expiry time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing url: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("url %q is not absolute", rawURL)
+	}
+
+	query := u.Query()
+	query.Del("sig")
+	query.Set("exp", strconv.FormatInt(expiry.Unix(), 10))
+	u.RawQuery = query.Encode()
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(u.EscapedPath() + "?" + u.RawQuery))
+
+	query.Set("sig", hex.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
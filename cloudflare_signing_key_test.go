@@ -0,0 +1,109 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestSignStreamToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	expiry := time.Now().Add(time.Hour).Unix()
+	for _, encoded := range []string{privateKey, base64.StdEncoding.EncodeToString([]byte(privateKey))} {
+		token, err := signStreamToken(encoded, "testkeyid", streamTokenClaims{
+			Subject:     "testvideoid",
+			Expiry:      expiry,
+			AccessRules: []interface{}{map[string]interface{}{"type": "any", "action": "block"}},
+		})
+		require.NoError(t, err)
+
+		parsed, err := jwt.ParseSigned(token)
+		require.NoError(t, err)
+		require.Equal(t, "testkeyid", parsed.Headers[0].KeyID)
+
+		var claims streamTokenClaims
+		require.NoError(t, parsed.Claims(&key.PublicKey, &claims))
+		require.Equal(t, "testvideoid", claims.Subject)
+		require.Equal(t, "testkeyid", claims.KeyID)
+		require.Equal(t, expiry, claims.Expiry)
+		require.Len(t, claims.AccessRules, 1)
+	}
+
+	_, err = signStreamToken("not a key", "testkeyid", streamTokenClaims{})
+	require.Error(t, err)
+}
+
+func TestSignImagesURL(t *testing.T) {
+	expiry := time.Unix(1700000000, 0)
+
+	signed, err := signImagesURL("testkey", "https://imagedelivery.net/hash/imageid/public?sig=stale", expiry)
+	require.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	require.Equal(t, "1700000000", u.Query().Get("exp"))
+
+	mac := hmac.New(sha256.New, []byte("testkey"))
+	mac.Write([]byte("/hash/imageid/public?exp=1700000000"))
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), u.Query().Get("sig"))
+
+	_, err = signImagesURL("testkey", "/hash/imageid/public", expiry)
+	require.Error(t, err)
+}
+
+func TestSignMaxTTL(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "signing-key/videos",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"key_type":    signingKeyTypeStream,
+			"account_id":  accountId,
+			"key_id":      "testkeyid",
+			"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+			"max_ttl":     "2h",
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	sign := func(ttl string) *logical.Response {
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sign/videos",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"video_id": "testvideoid",
+				"ttl":      ttl,
+			},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	require.False(t, sign("2h").IsError())
+	require.True(t, sign("8760h").IsError())
+}
@@ -0,0 +1,41 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// turnstileWidget is the subset of the Turnstile widget API response the backend uses.
+// cloudflare-go does not yet wrap the Turnstile endpoints.
+type turnstileWidget struct {
+	SiteKey string `json:"sitekey"`
+	Secret  string `json:"secret"`
+	Name    string `json:"name"`
+}
+
+type rotateTurnstileSecretRequest struct {
+	InvalidateImmediately bool `json:"invalidate_immediately"`
+}
+
+func rotateTurnstileSecret(ctx context.Context, c cloudflareClient, accountId string, siteKey string, invalidateImmediately bool) (string, error) {
+	uri := fmt.Sprintf("/accounts/%s/challenges/widgets/%s/rotate_secret", accountId, siteKey)
+	raw, err := c.Raw(ctx, http.MethodPost, uri, rotateTurnstileSecretRequest{
+		InvalidateImmediately: invalidateImmediately,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("error rotating turnstile widget secret: %w", err)
+	}
+
+	var widget turnstileWidget
+	if err := json.Unmarshal(raw, &widget); err != nil {
+		return "", fmt.Errorf("error decoding turnstile widget: %w", err)
+	}
+
+	if widget.Secret == "" {
+		return "", fmt.Errorf("error rotating turnstile widget secret: no secret returned")
+	}
+
+	return widget.Secret, nil
+}
@@ -0,0 +1,237 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	workerSecretStoragePrefix = "worker-secret/"
+)
+
+var (
+	workerScriptNameRegex  = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]*$`)
+	workerBindingNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// workerSecretTarget is a Worker secret binding that generated credentials are written to,
+// declared on roles as script:BINDING, or script:BINDING:field to write a single response field.
+type workerSecretTarget struct {
+	Script  string
+	Binding string
+	Field   string
+}
+
+// workerSecretOwner records which credential last wrote a Worker secret, so that revoking
+// an older credential does not remove the value of a newer one.
+type workerSecretOwner struct {
+	Owner string `json:"owner"`
+}
+
+func parseWorkerSecretTarget(target string) (*workerSecretTarget, error) {
+	parts := strings.Split(target, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("worker secret %q must be in the form script:BINDING[:field]", target)
+	}
+
+	if !workerScriptNameRegex.MatchString(parts[0]) {
+		return nil, fmt.Errorf("worker secret %q has an invalid script name", target)
+	}
+
+	if !workerBindingNameRegex.MatchString(parts[1]) {
+		return nil, fmt.Errorf("worker secret %q has an invalid binding name", target)
+	}
+
+	t := &workerSecretTarget{
+		Script:  parts[0],
+		Binding: parts[1],
+	}
+
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return nil, fmt.Errorf("worker secret %q has an empty field", target)
+		}
+		t.Field = parts[2]
+	}
+
+	return t, nil
+}
+
+// workerSecretValue returns the value written to a target, either a single field of the
+// credential or, if no field is set, the whole credential as JSON.
+func workerSecretValue(t *workerSecretTarget, data map[string]interface{}) (string, error) {
+	if t.Field == "" {
+		value, err := json.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		return string(value), nil
+	}
+
+	value, ok := data[t.Field]
+	if !ok {
+		return "", fmt.Errorf("credential has no field %q", t.Field)
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+func workerSecretStorageKey(accountId string, t *workerSecretTarget) string {
+	return workerSecretStoragePrefix + accountId + "/" + t.Script + "/" + t.Binding
+}
+
+// syncWorkerSecrets writes a credential into each target Worker secret and records owner as
+// the credential responsible for the current value.
+func (b *cloudflareBackend) syncWorkerSecrets(ctx context.Context, s logical.Storage, accountId string, targets []string, owner string, data map[string]interface{}) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	client, err := b.getClient(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	b.workerSecretLock.Lock()
+	defer b.workerSecretLock.Unlock()
+
+	for _, target := range targets {
+		t, err := parseWorkerSecretTarget(target)
+		if err != nil {
+			return err
+		}
+
+		value, err := workerSecretValue(t, data)
+		if err != nil {
+			return fmt.Errorf("error writing worker secret %q: %w", target, err)
+		}
+
+		_, err = client.SetWorkersSecret(ctx, cloudflare.AccountIdentifier(accountId), cloudflare.SetWorkersSecretParams{
+			ScriptName: t.Script,
+			Secret: &cloudflare.WorkersPutSecretRequest{
+				Name: t.Binding,
+				Text: value,
+				Type: cloudflare.WorkerSecretTextBindingType,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error writing worker secret %q: %w", target, err)
+		}
+
+		entry, err := logical.StorageEntryJSON(workerSecretStorageKey(accountId, t), &workerSecretOwner{Owner: owner})
+		if err != nil {
+			return err
+		}
+
+		if err := s.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseWorkerSecrets deletes each target Worker secret that owner still holds. Secrets that
+// have since been replaced by another credential are left in place.
+func (b *cloudflareBackend) releaseWorkerSecrets(ctx context.Context, s logical.Storage, accountId string, targets []string, owner string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	client, err := b.getClient(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	b.workerSecretLock.Lock()
+	defer b.workerSecretLock.Unlock()
+
+	for _, target := range targets {
+		t, err := parseWorkerSecretTarget(target)
+		if err != nil {
+			return err
+		}
+
+		key := workerSecretStorageKey(accountId, t)
+
+		entry, err := s.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if entry == nil {
+			continue
+		}
+
+		var current workerSecretOwner
+		if err := entry.DecodeJSON(&current); err != nil {
+			return err
+		}
+
+		if current.Owner != owner {
+			continue
+		}
+
+		_, err = client.DeleteWorkersSecret(ctx, cloudflare.AccountIdentifier(accountId), cloudflare.DeleteWorkersSecretParams{
+			ScriptName: t.Script,
+			SecretName: t.Binding,
+		})
+		var notFoundErr *cloudflare.NotFoundError
+		if err != nil && !errors.As(err, &notFoundErr) {
+			return fmt.Errorf("error deleting worker secret %q: %w", target, err)
+		}
+
+		if err := s.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removedWorkerSecrets returns the targets in oldTargets whose Worker secret is no longer written
+// to by newTargets, either because the binding was dropped or because the account changed.
+func removedWorkerSecrets(oldAccountId string, oldTargets []string, newAccountId string, newTargets []string) []string {
+	kept := make(map[string]bool)
+	for _, target := range newTargets {
+		if t, err := parseWorkerSecretTarget(target); err == nil {
+			kept[workerSecretStorageKey(newAccountId, t)] = true
+		}
+	}
+
+	var removed []string
+	for _, target := range oldTargets {
+		t, err := parseWorkerSecretTarget(target)
+		if err != nil || !kept[workerSecretStorageKey(oldAccountId, t)] {
+			removed = append(removed, target)
+		}
+	}
+
+	return removed
+}
+
+// internalDataStrings converts a string slice stored in the internal data of a secret, which has
+// been round tripped through JSON, back into a string slice.
+func internalDataStrings(raw interface{}) []string {
+	list, ok := raw.([]interface{})
+	if !ok {
+		values, _ := raw.([]string)
+		return values
+	}
+
+	values := make([]string, 0, len(list))
+	for _, value := range list {
+		if value, ok := value.(string); ok {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
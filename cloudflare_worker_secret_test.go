@@ -0,0 +1,40 @@
+package cloudflare_secrets_engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkerSecretTarget(t *testing.T) {
+	target, err := parseWorkerSecretTarget("api-worker:ACCESS_CLIENT_SECRET:client_secret")
+	require.NoError(t, err)
+	require.Equal(t, &workerSecretTarget{Script: "api-worker", Binding: "ACCESS_CLIENT_SECRET", Field: "client_secret"}, target)
+
+	target, err = parseWorkerSecretTarget("api-worker:ACCESS_TOKEN")
+	require.NoError(t, err)
+	require.Equal(t, "", target.Field)
+
+	for _, invalid := range []string{"api-worker", "api-worker:", "Api Worker:TOKEN", "api-worker:1TOKEN", "api-worker:TOKEN:", "a:b:c:d"} {
+		_, err := parseWorkerSecretTarget(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestWorkerSecretValue(t *testing.T) {
+	data := map[string]interface{}{
+		"client_id":     "testclientid",
+		"client_secret": "testclientsecret",
+	}
+
+	value, err := workerSecretValue(&workerSecretTarget{Field: "client_secret"}, data)
+	require.NoError(t, err)
+	require.Equal(t, "testclientsecret", value)
+
+	value, err = workerSecretValue(&workerSecretTarget{}, data)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"client_id":"testclientid","client_secret":"testclientsecret"}`, value)
+
+	_, err = workerSecretValue(&workerSecretTarget{Field: "token"}, data)
+	require.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	eventCredentialIssue  logical.EventType = "cloudflare/credential-issue"
+	eventCredentialRenew  logical.EventType = "cloudflare/credential-renew"
+	eventCredentialRevoke logical.EventType = "cloudflare/credential-revoke"
+	eventStaticRoleRotate logical.EventType = "cloudflare/static-role-rotate"
+
+	eventCredentialIdleRevoke logical.EventType = "cloudflare/credential-idle-revoke"
+
+	// eventOrphanRecorded is sent when a service token could not be deleted after a failed
+	// issuance, and eventOrphanDeleted once an operator has deleted it at Cloudflare.
+	eventOrphanRecorded logical.EventType = "cloudflare/orphan-recorded"
+	eventOrphanDeleted  logical.EventType = "cloudflare/orphan-deleted"
+)
+
+// eventInternalDataKeys are the internal data fields of a lease that are copied into its events.
+// Only identifiers are listed, never credentials.
+var eventInternalDataKeys = []string{
+	"role",
+	"account_id",
+	"zone_id",
+	"token_id",
+	"token_ids",
+	"token_owner",
+	"certificate_id",
+	"record_id",
+	"entry_id",
+	"grant_id",
+	"member_id",
+	"issue_request_id",
+}
+
+// sendEvent publishes a credential lifecycle event. Events are best effort: failing to send one,
+// including on Vault versions without events, never fails the operation.
+func (b *cloudflareBackend) sendEvent(ctx context.Context, eventType logical.EventType, metadata map[string]interface{}) {
+	event, err := logical.NewEvent()
+	if err != nil {
+		b.Logger().Warn("error creating event", "type", eventType, "error", err)
+		return
+	}
+
+	event.Metadata, err = structpb.NewStruct(metadata)
+	if err != nil {
+		b.Logger().Warn("error creating event", "type", eventType, "error", err)
+		return
+	}
+
+	err = b.SendEvent(ctx, eventType, event)
+	if errors.Is(err, framework.ErrNoEvents) {
+		return
+	}
+	if err != nil {
+		b.Logger().Warn("error sending event", "type", eventType, "error", err)
+	}
+}
+
+// secretEventMetadata returns the metadata of an event about a lease: its identifiers, and the
+// lease ID once Vault has assigned one. Vault assigns lease IDs after the plugin returns, so issue
+// events never carry one. Instead every event of a lease carries issue_request_id, the ID of the
+// request that issued it, which links issue events with later renew and revoke events.
+func secretEventMetadata(credentialType string, secret *logical.Secret) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"credential_type": credentialType,
+	}
+
+	if secret == nil {
+		return metadata
+	}
+
+	if secret.LeaseID != "" {
+		metadata["lease_id"] = secret.LeaseID
+	}
+
+	for _, key := range eventInternalDataKeys {
+		switch value := secret.InternalData[key].(type) {
+		case string:
+			metadata[key] = value
+		case []string:
+			metadata[key] = strings.Join(value, ",")
+		case []interface{}:
+			metadata[key] = strings.Join(internalDataStrings(value), ",")
+		}
+	}
+
+	return metadata
+}
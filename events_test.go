@@ -0,0 +1,160 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+type testEventSender struct {
+	lock   sync.Mutex
+	events map[logical.EventType][]*logical.EventData
+}
+
+func (s *testEventSender) Send(ctx context.Context, eventType logical.EventType, event *logical.EventData) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.events[eventType] = append(s.events[eventType], event)
+	return nil
+}
+
+func getTestBackendWithEvents(t *testing.T, client cloudflareClient) (*cloudflareBackend, logical.Storage, *testEventSender) {
+	t.Helper()
+
+	events := &testEventSender{events: make(map[logical.EventType][]*logical.EventData)}
+
+	config := logical.TestBackendConfig()
+	config.StorageView = new(logical.InmemStorage)
+	config.Logger = hclog.NewNullLogger()
+	config.System = logical.TestSystemView()
+	config.EventsSender = events
+
+	b := backend(func(*cloudflareConfig) (cloudflareClient, error) {
+		return client, nil
+	})
+	require.NoError(t, b.Setup(context.Background(), config))
+
+	return b, config.StorageView, events
+}
+
+func TestCredentialEvents(t *testing.T) {
+	client := newFakeClient()
+	b, s, events := getTestBackendWithEvents(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+	})
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		ID:        "issuing-request-id",
+		Operation: logical.ReadOperation,
+		Path:      "service-token/ci",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, events.events[eventCredentialIssue], 1)
+	issued := events.events[eventCredentialIssue][0].Metadata.AsMap()
+	require.Equal(t, "ci", issued["role"])
+	require.Equal(t, accountId, issued["account_id"])
+	require.Equal(t, resp.Data["token_id"], issued["token_id"])
+	require.Equal(t, "issuing-request-id", issued["request_id"])
+	require.Equal(t, "issuing-request-id", issued["issue_request_id"])
+	require.NotContains(t, issued, "lease_id")
+	require.NotContains(t, issued, "client_secret")
+
+	resp.Secret.LeaseID = "cloudflare/service-token/ci/abc123"
+	_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+	require.NoError(t, err)
+
+	require.Len(t, events.events[eventCredentialRevoke], 1)
+	revoked := events.events[eventCredentialRevoke][0].Metadata.AsMap()
+	require.Equal(t, "cloudflare/service-token/ci/abc123", revoked["lease_id"])
+	require.Equal(t, resp.Data["token_id"], revoked["token_id"])
+	require.Equal(t, "issuing-request-id", revoked["issue_request_id"])
+}
+
+func TestOrphanEvents(t *testing.T) {
+	client := newFakeClient()
+	b, s, events := getTestBackendWithEvents(t, client)
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+		"max_batch":       2,
+	})
+	require.NoError(t, err)
+
+	serviceErr := cloudflare.NewServiceError(&cloudflare.Error{StatusCode: 503})
+	client.deleteErr = &serviceErr
+	client.failCreateAfter = 1
+
+	_, err = testServiceTokenRead(t, b, s, "ci", map[string]interface{}{"count": 2})
+	require.NoError(t, err)
+	require.Len(t, client.tokens, 1)
+
+	var tokenId string
+	for id := range client.tokens {
+		tokenId = id
+	}
+
+	require.Len(t, events.events[eventOrphanRecorded], 1)
+	recorded := events.events[eventOrphanRecorded][0].Metadata.AsMap()
+	require.Equal(t, "ci", recorded["role"])
+	require.Equal(t, accountId, recorded["account_id"])
+	require.Equal(t, tokenId, recorded["token_id"])
+
+	client.deleteErr = nil
+	delete(client.tokens, tokenId)
+
+	_, err = b.countOrphanedTokens(context.Background(), s)
+	require.NoError(t, err)
+
+	require.Len(t, events.events[eventOrphanDeleted], 1)
+	deleted := events.events[eventOrphanDeleted][0].Metadata.AsMap()
+	require.Equal(t, "ci", deleted["role"])
+	require.Equal(t, accountId, deleted["account_id"])
+	require.Equal(t, tokenId, deleted["token_id"])
+}
+
+func TestStaticRoleEvents(t *testing.T) {
+	b, s, events := getTestBackendWithEvents(t, newFakeClient())
+
+	_, err := testStaticRoleCreate(t, b, s, "widget", map[string]interface{}{
+		"credential_type": "turnstile",
+		"account_id":      accountId,
+		"sitekey":         "testsitekey",
+	})
+	require.NoError(t, err)
+	require.Len(t, events.events[eventStaticRoleRotate], 1)
+
+	// Reading the current credential issues nothing.
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "static-creds/widget",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp.Error())
+	require.Empty(t, events.events[eventCredentialIssue])
+	require.Len(t, events.events[eventStaticRoleRotate], 1)
+
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotate-role/widget",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.Len(t, events.events[eventStaticRoleRotate], 2)
+	rotated := events.events[eventStaticRoleRotate][1].Metadata.AsMap()
+	require.Equal(t, "widget", rotated["role"])
+	require.Equal(t, "testsitekey", rotated["sitekey"])
+}
@@ -0,0 +1,406 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI is an in-process fake of the Cloudflare v4 API endpoints used for service tokens, API
+// tokens and audit logs. It keeps tokens in memory, records their creation and deletion in a single
+// audit log served as both the account and the user audit log, knows a fixed set of permission
+// groups and zones, and can be told to fail or slow down requests.
+type fakeAPI struct {
+	*httptest.Server
+
+	lock             sync.Mutex
+	nextId           int
+	serviceTokens    map[string]cloudflare.AccessServiceTokenCreateResponse
+	apiTokens        map[string]cloudflare.APIToken
+	permissionGroups map[string]string
+	zones            map[string]string
+	lastSeen         map[string]time.Time
+	auditLogs        []cloudflare.AuditLog
+
+	failures []fakeAPIFailure
+	latency  time.Duration
+}
+
+type fakeAPIFailure struct {
+	status int
+	count  int
+}
+
+const (
+	fakePermissionGroupId = "c8fed203ed3043cba015a93ad1616f1f"
+	fakeZoneId            = "023e105f4ecef8ad9ca31a8372d0c353"
+)
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+
+	f := &fakeAPI{
+		serviceTokens: make(map[string]cloudflare.AccessServiceTokenCreateResponse),
+		lastSeen:      make(map[string]time.Time),
+		apiTokens:     make(map[string]cloudflare.APIToken),
+		permissionGroups: map[string]string{
+			fakePermissionGroupId: "Zone Read",
+		},
+		zones: map[string]string{
+			fakeZoneId: "example.com",
+		},
+	}
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// getTestBackendWithFakeAPI returns a backend configured to use a new fake Cloudflare API.
+func getTestBackendWithFakeAPI(t *testing.T) (*cloudflareBackend, logical.Storage, *fakeAPI) {
+	t.Helper()
+
+	f := newFakeAPI(t)
+
+	config := logical.TestBackendConfig()
+	config.StorageView = new(logical.InmemStorage)
+	config.Logger = hclog.NewNullLogger()
+	config.System = logical.TestSystemView()
+
+	// The client trusts the fake API's self-signed certificate.
+	b := backend(func(config *cloudflareConfig) (cloudflareClient, error) {
+		return newClientWithTransport(config, f.Client().Transport)
+	})
+	require.NoError(t, b.Setup(context.Background(), config))
+	s := config.StorageView
+
+	err := testConfigCreate(t, b, s, map[string]interface{}{
+		"api_token": apiToken,
+		"base_url":  f.URL,
+	})
+	require.NoError(t, err)
+
+	return b, s, f
+}
+
+// slowDown delays every response by latency.
+func (f *fakeAPI) slowDown(latency time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.latency = latency
+}
+
+// markSeen records that Cloudflare Access last saw a service token at when.
+func (f *fakeAPI) markSeen(tokenId string, when time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.lastSeen[tokenId] = when
+}
+
+// addAuditLog appends an entry to the audit log.
+func (f *fakeAPI) addAuditLog(log cloudflare.AuditLog) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.auditLogs = append(f.auditLogs, log)
+}
+
+// audit appends an entry for an action of the API token used by Vault. The caller holds the lock.
+func (f *fakeAPI) audit(action string, resourceType string, resourceId string) {
+	f.auditLogs = append(f.auditLogs, cloudflare.AuditLog{
+		ID:       fmt.Sprintf("audit-%d", len(f.auditLogs)+1),
+		When:     time.Now(),
+		Action:   cloudflare.AuditLogAction{Type: action, Result: true},
+		Actor:    cloudflare.AuditLogActor{ID: "vault-user", Email: "vault@example.com", Type: "user"},
+		Resource: cloudflare.AuditLogResource{ID: resourceId, Type: resourceType},
+	})
+}
+
+// failNext makes the next count requests fail with status.
+func (f *fakeAPI) failNext(count int, status int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.failures = append(f.failures, fakeAPIFailure{status: status, count: count})
+}
+
+func (f *fakeAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	latency := f.latency
+
+	if len(f.failures) > 0 {
+		failure := &f.failures[0]
+		failure.count--
+		if failure.count <= 0 {
+			f.failures = f.failures[1:]
+		}
+		f.lock.Unlock()
+
+		w.Header().Set("Retry-After", "0")
+		fakeAPIError(w, failure.status, 10000, http.StatusText(failure.status))
+		return
+	}
+	f.lock.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+apiToken {
+		fakeAPIError(w, http.StatusForbidden, 9109, "Invalid access token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case len(parts) >= 4 && parts[0] == "accounts" && parts[2] == "access" && parts[3] == "service_tokens":
+		f.serveServiceTokens(w, r, parts[4:])
+	case len(parts) == 3 && parts[0] == "accounts" && parts[2] == "audit_logs" && r.Method == http.MethodGet:
+		f.serveAuditLogs(w, r)
+	case len(parts) == 2 && parts[0] == "user" && parts[1] == "audit_logs" && r.Method == http.MethodGet:
+		f.serveAuditLogs(w, r)
+	case len(parts) >= 2 && parts[0] == "user" && parts[1] == "tokens":
+		f.serveAPITokens(w, r, "user", parts[2:])
+	case len(parts) >= 3 && parts[0] == "accounts" && parts[2] == "tokens":
+		f.serveAPITokens(w, r, parts[1], parts[3:])
+	default:
+		fakeAPIError(w, http.StatusNotFound, 7003, "Could not route to "+r.URL.Path)
+	}
+}
+
+func (f *fakeAPI) serveServiceTokens(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var request struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Name == "" {
+			fakeAPIError(w, http.StatusBadRequest, 12130, "name is required")
+			return
+		}
+
+		f.nextId++
+		now := time.Now()
+		token := cloudflare.AccessServiceTokenCreateResponse{
+			CreatedAt:    &now,
+			ID:           fmt.Sprintf("service-token-%d", f.nextId),
+			Name:         request.Name,
+			ClientID:     fmt.Sprintf("client-%d.access", f.nextId),
+			ClientSecret: fmt.Sprintf("secret-%d", f.nextId),
+		}
+		f.serviceTokens[token.ID] = token
+		f.audit("create", "access.service_token", token.ID)
+		fakeAPIResult(w, token)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		token, ok := f.serviceTokens[parts[0]]
+		if !ok {
+			fakeAPIError(w, http.StatusNotFound, 12128, "access.api.error.not_found")
+			return
+		}
+		result := map[string]interface{}{"id": token.ID, "name": token.Name, "client_id": token.ClientID}
+		if lastSeen, ok := f.lastSeen[token.ID]; ok {
+			result["last_seen_at"] = lastSeen
+		}
+		fakeAPIResult(w, result)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		token, ok := f.serviceTokens[parts[0]]
+		if !ok {
+			fakeAPIError(w, http.StatusNotFound, 12128, "access.api.error.not_found")
+			return
+		}
+		delete(f.serviceTokens, parts[0])
+		f.audit("delete", "access.service_token", token.ID)
+		fakeAPIResult(w, map[string]string{"id": token.ID, "name": token.Name})
+	case len(parts) == 2 && parts[1] == "refresh" && r.Method == http.MethodPost:
+		token, ok := f.serviceTokens[parts[0]]
+		if !ok {
+			fakeAPIError(w, http.StatusNotFound, 12128, "access.api.error.not_found")
+			return
+		}
+		fakeAPIResult(w, map[string]string{"id": token.ID, "name": token.Name})
+	default:
+		fakeAPIError(w, http.StatusMethodNotAllowed, 10405, "Method not allowed")
+	}
+}
+
+func (f *fakeAPI) serveAPITokens(w http.ResponseWriter, r *http.Request, owner string, parts []string) {
+	if len(parts) == 0 && r.Method == http.MethodPost {
+		var token cloudflare.APIToken
+		if err := json.NewDecoder(r.Body).Decode(&token); err != nil {
+			fakeAPIError(w, http.StatusBadRequest, 6003, "Invalid request body")
+			return
+		}
+
+		if err := f.validateAPIToken(token); err != nil {
+			fakeAPIError(w, http.StatusBadRequest, 1001, err.Error())
+			return
+		}
+
+		f.nextId++
+		token.ID = fmt.Sprintf("api-token-%d", f.nextId)
+		token.Value = fmt.Sprintf("value-%d", f.nextId)
+		token.Status = "active"
+		f.apiTokens[owner+"/"+token.ID] = token
+		f.audit("create", "api_token", token.ID)
+		fakeAPIResult(w, token)
+		return
+	}
+
+	if len(parts) != 1 {
+		fakeAPIError(w, http.StatusMethodNotAllowed, 10405, "Method not allowed")
+		return
+	}
+
+	key := owner + "/" + parts[0]
+	token, ok := f.apiTokens[key]
+	if !ok {
+		fakeAPIError(w, http.StatusNotFound, 1003, "Invalid token")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		token.Value = ""
+		fakeAPIResult(w, token)
+	case http.MethodPut:
+		var update cloudflare.APIToken
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			fakeAPIError(w, http.StatusBadRequest, 6003, "Invalid request body")
+			return
+		}
+		update.ID = token.ID
+		update.Value = token.Value
+		f.apiTokens[key] = update
+		update.Value = ""
+		fakeAPIResult(w, update)
+	case http.MethodDelete:
+		delete(f.apiTokens, key)
+		f.audit("delete", "api_token", token.ID)
+		fakeAPIResult(w, map[string]string{"id": token.ID})
+	default:
+		fakeAPIError(w, http.StatusMethodNotAllowed, 10405, "Method not allowed")
+	}
+}
+
+// serveAuditLogs lists the audit log most recent first, honouring since, page and per_page.
+func (f *fakeAPI) serveAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			fakeAPIError(w, http.StatusBadRequest, 10001, "Invalid since")
+			return
+		}
+	}
+
+	logs := []cloudflare.AuditLog{}
+	for i := len(f.auditLogs) - 1; i >= 0; i-- {
+		if !f.auditLogs[i].When.Before(since) {
+			logs = append(logs, f.auditLogs[i])
+		}
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	if perPage < 1 {
+		perPage = 100
+	}
+
+	start := (page - 1) * perPage
+	if start > len(logs) {
+		start = len(logs)
+	}
+	end := start + perPage
+	if end > len(logs) {
+		end = len(logs)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"errors":   []interface{}{},
+		"messages": []interface{}{},
+		"result":   logs[start:end],
+		"result_info": cloudflare.ResultInfo{
+			Page:       page,
+			PerPage:    perPage,
+			TotalPages: (len(logs) + perPage - 1) / perPage,
+			Count:      end - start,
+			Total:      len(logs),
+		},
+	})
+}
+
+// validateAPIToken checks that policies only refer to known permission groups and zones.
+func (f *fakeAPI) validateAPIToken(token cloudflare.APIToken) error {
+	if len(token.Policies) == 0 {
+		return fmt.Errorf("policies are required")
+	}
+
+	for _, policy := range token.Policies {
+		for _, group := range policy.PermissionGroups {
+			if _, ok := f.permissionGroups[group.ID]; !ok {
+				return fmt.Errorf("unknown permission group %q", group.ID)
+			}
+		}
+
+		for resource := range policy.Resources {
+			if zoneId, ok := strings.CutPrefix(resource, "com.cloudflare.api.account.zone."); ok && zoneId != "*" {
+				if _, ok := f.zones[zoneId]; !ok {
+					return fmt.Errorf("unknown zone %q", zoneId)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func fakeAPIResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"errors":   []interface{}{},
+		"messages": []interface{}{},
+		"result":   result,
+	})
+}
+
+func fakeAPIError(w http.ResponseWriter, status int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("cf-ray", "7d1c2a3b4c5d6e7f-LHR")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  false,
+		"errors":   []cloudflare.ResponseInfo{{Code: code, Message: message}},
+		"messages": []interface{}{},
+		"result":   nil,
+	})
+}
@@ -0,0 +1,461 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// fakeClient is an in-memory stand-in for the service token, user API token, Access policy, Origin
+// CA, API Shield client certificate, account member, DNS record, account IP Access rule and Worker
+// secret endpoints of Cloudflare. Raw only serves service token reads, Origin CA and client
+// certificates, and Turnstile secret rotation. Calling any other method panics.
+type fakeClient struct {
+	cloudflareClient
+
+	lock      sync.Mutex
+	nextId    int
+	tokens    map[string]cloudflare.AccessServiceTokenCreateResponse
+	apiTokens map[string]cloudflare.APIToken
+	refreshes map[string]int
+	policies  map[string]cloudflare.AccessPolicy
+	originCA  map[string]cloudflare.OriginCACertificate
+	members   map[string]cloudflare.AccountMember
+	records   map[string]cloudflare.DNSRecord
+	rules     map[string]cloudflare.AccessRule
+
+	// clientCerts maps the IDs of client certificates to their zone.
+	clientCerts map[string]string
+	// workerSecrets holds Worker secret values keyed by script/binding.
+	workerSecrets map[string]string
+
+	// err, if set, is returned by every call and nothing is changed.
+	err error
+	// deleteErr, if set, is returned when deleting service tokens.
+	deleteErr error
+	// failCreateAfter, if positive, fails service token creation once that many tokens have been created.
+	failCreateAfter int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		tokens:        make(map[string]cloudflare.AccessServiceTokenCreateResponse),
+		apiTokens:     make(map[string]cloudflare.APIToken),
+		refreshes:     make(map[string]int),
+		policies:      make(map[string]cloudflare.AccessPolicy),
+		originCA:      make(map[string]cloudflare.OriginCACertificate),
+		members:       make(map[string]cloudflare.AccountMember),
+		records:       make(map[string]cloudflare.DNSRecord),
+		rules:         make(map[string]cloudflare.AccessRule),
+		clientCerts:   make(map[string]string),
+		workerSecrets: make(map[string]string),
+	}
+}
+
+func fakeNotFoundError() error {
+	err := cloudflare.NewNotFoundError(&cloudflare.Error{
+		StatusCode: 404,
+		Type:       cloudflare.ErrorTypeNotFound,
+		ErrorCodes: []int{10007},
+	})
+	return &err
+}
+
+func (c *fakeClient) CreateAccessServiceToken(ctx context.Context, accountID, name string) (cloudflare.AccessServiceTokenCreateResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.AccessServiceTokenCreateResponse{}, c.err
+	}
+
+	if c.failCreateAfter > 0 && c.nextId >= c.failCreateAfter {
+		err := cloudflare.NewServiceError(&cloudflare.Error{StatusCode: 500})
+		return cloudflare.AccessServiceTokenCreateResponse{}, &err
+	}
+
+	c.nextId++
+	token := cloudflare.AccessServiceTokenCreateResponse{
+		ID:           fmt.Sprintf("token-%d", c.nextId),
+		Name:         name,
+		ClientID:     fmt.Sprintf("client-%d.access", c.nextId),
+		ClientSecret: fmt.Sprintf("secret-%d", c.nextId),
+	}
+	c.tokens[token.ID] = token
+
+	return token, nil
+}
+
+func (c *fakeClient) RefreshAccessServiceToken(ctx context.Context, rc *cloudflare.ResourceContainer, id string) (cloudflare.AccessServiceTokenRefreshResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.AccessServiceTokenRefreshResponse{}, c.err
+	}
+
+	if _, ok := c.tokens[id]; !ok {
+		return cloudflare.AccessServiceTokenRefreshResponse{}, fakeNotFoundError()
+	}
+
+	c.refreshes[id]++
+
+	return cloudflare.AccessServiceTokenRefreshResponse{ID: id}, nil
+}
+
+func (c *fakeClient) DeleteAccessServiceToken(ctx context.Context, accountID, uuid string) (cloudflare.AccessServiceTokenUpdateResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.AccessServiceTokenUpdateResponse{}, c.err
+	}
+
+	if c.deleteErr != nil {
+		return cloudflare.AccessServiceTokenUpdateResponse{}, c.deleteErr
+	}
+
+	if _, ok := c.tokens[uuid]; !ok {
+		return cloudflare.AccessServiceTokenUpdateResponse{}, fakeNotFoundError()
+	}
+
+	delete(c.tokens, uuid)
+
+	return cloudflare.AccessServiceTokenUpdateResponse{ID: uuid}, nil
+}
+
+func (c *fakeClient) CreateAPIToken(ctx context.Context, token cloudflare.APIToken) (cloudflare.APIToken, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.APIToken{}, c.err
+	}
+
+	c.nextId++
+	token.ID = fmt.Sprintf("api-token-%d", c.nextId)
+	token.Value = fmt.Sprintf("value-%d", c.nextId)
+	c.apiTokens[token.ID] = token
+
+	return token, nil
+}
+
+func (c *fakeClient) GetAPIToken(ctx context.Context, tokenID string) (cloudflare.APIToken, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.APIToken{}, c.err
+	}
+
+	token, ok := c.apiTokens[tokenID]
+	if !ok {
+		return cloudflare.APIToken{}, fakeNotFoundError()
+	}
+
+	return token, nil
+}
+
+func (c *fakeClient) UpdateAPIToken(ctx context.Context, tokenID string, token cloudflare.APIToken) (cloudflare.APIToken, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.APIToken{}, c.err
+	}
+
+	if _, ok := c.apiTokens[tokenID]; !ok {
+		return cloudflare.APIToken{}, fakeNotFoundError()
+	}
+	c.apiTokens[tokenID] = token
+
+	return token, nil
+}
+
+func (c *fakeClient) DeleteAPIToken(ctx context.Context, tokenID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	if _, ok := c.apiTokens[tokenID]; !ok {
+		return fakeNotFoundError()
+	}
+	delete(c.apiTokens, tokenID)
+
+	return nil
+}
+
+func (c *fakeClient) AccessPolicy(ctx context.Context, accountID, applicationID, policyID string) (cloudflare.AccessPolicy, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.AccessPolicy{}, c.err
+	}
+
+	policy, ok := c.policies[policyID]
+	if !ok {
+		return cloudflare.AccessPolicy{}, fakeNotFoundError()
+	}
+
+	return policy, nil
+}
+
+// UpdateAccessPolicy stores the policy as Cloudflare returns it, with rules decoded from JSON.
+func (c *fakeClient) UpdateAccessPolicy(ctx context.Context, accountID, applicationID string, accessPolicy cloudflare.AccessPolicy) (cloudflare.AccessPolicy, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.AccessPolicy{}, c.err
+	}
+
+	if _, ok := c.policies[accessPolicy.ID]; !ok {
+		return cloudflare.AccessPolicy{}, fakeNotFoundError()
+	}
+
+	raw, err := json.Marshal(accessPolicy)
+	if err != nil {
+		return cloudflare.AccessPolicy{}, err
+	}
+
+	var policy cloudflare.AccessPolicy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return cloudflare.AccessPolicy{}, err
+	}
+	c.policies[policy.ID] = policy
+
+	return policy, nil
+}
+
+func (c *fakeClient) Raw(ctx context.Context, method, endpoint string, data interface{}, headers http.Header) (json.RawMessage, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	switch {
+	case method == http.MethodGet && len(parts) == 5 && parts[0] == "accounts" && parts[3] == "service_tokens":
+		token, ok := c.tokens[parts[4]]
+		if !ok {
+			return nil, fakeNotFoundError()
+		}
+
+		return json.Marshal(map[string]string{"id": token.ID, "name": token.Name, "client_id": token.ClientID})
+	case method == http.MethodPost && endpoint == "/certificates":
+		params := data.(cloudflare.CreateOriginCertificateParams)
+		c.nextId++
+		cert := cloudflare.OriginCACertificate{
+			ID:              fmt.Sprintf("origin-ca-%d", c.nextId),
+			Certificate:     "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----",
+			Hostnames:       params.Hostnames,
+			RequestType:     params.RequestType,
+			RequestValidity: params.RequestValidity,
+			ExpiresOn:       time.Now().AddDate(0, 0, params.RequestValidity).Truncate(time.Second),
+			CSR:             params.CSR,
+		}
+		c.originCA[cert.ID] = cert
+
+		return json.Marshal(cert)
+	case method == http.MethodPost && len(parts) == 3 && parts[0] == "zones" && parts[2] == "client_certificates":
+		params := data.(createClientCertificateRequest)
+		c.nextId++
+		id := fmt.Sprintf("client-cert-%d", c.nextId)
+		c.clientCerts[id] = parts[1]
+
+		response := clientCertificateResponse{
+			ID:           id,
+			Certificate:  "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----",
+			SerialNumber: fmt.Sprint(c.nextId),
+			ExpiresOn:    time.Now().AddDate(0, 0, params.ValidityDays).UTC().Format(time.RFC3339),
+		}
+		response.CertificateAuthority.ID = "managed-ca"
+
+		return json.Marshal(response)
+	case method == http.MethodDelete && len(parts) == 4 && parts[0] == "zones" && parts[2] == "client_certificates":
+		if zone, ok := c.clientCerts[parts[3]]; !ok || zone != parts[1] {
+			return nil, fakeNotFoundError()
+		}
+		delete(c.clientCerts, parts[3])
+
+		return json.Marshal(map[string]string{"id": parts[3], "status": "pending_revocation"})
+	case method == http.MethodPost && len(parts) == 6 && parts[0] == "accounts" && parts[2] == "challenges" && parts[5] == "rotate_secret":
+		c.nextId++
+		return json.Marshal(map[string]string{"sitekey": parts[4], "secret": fmt.Sprintf("turnstile-secret-%d", c.nextId)})
+	default:
+		panic(fmt.Sprintf("fakeClient does not serve %s %s", method, endpoint))
+	}
+}
+
+func (c *fakeClient) RevokeOriginCACertificate(ctx context.Context, certificateID string) (*cloudflare.OriginCACertificateID, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if _, ok := c.originCA[certificateID]; !ok {
+		return nil, fakeNotFoundError()
+	}
+	delete(c.originCA, certificateID)
+
+	return &cloudflare.OriginCACertificateID{ID: certificateID}, nil
+}
+
+func (c *fakeClient) CreateDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.CreateDNSRecordParams) (cloudflare.DNSRecord, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.DNSRecord{}, c.err
+	}
+
+	c.nextId++
+	record := cloudflare.DNSRecord{
+		ID:      fmt.Sprintf("record-%d", c.nextId),
+		ZoneID:  rc.Identifier,
+		Type:    params.Type,
+		Name:    params.Name,
+		Content: params.Content,
+		TTL:     params.TTL,
+		Comment: params.Comment,
+	}
+	c.records[record.ID] = record
+
+	return record, nil
+}
+
+func (c *fakeClient) DeleteDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, recordID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	record, ok := c.records[recordID]
+	if !ok || record.ZoneID != rc.Identifier {
+		return fakeNotFoundError()
+	}
+	delete(c.records, recordID)
+
+	return nil
+}
+
+func (c *fakeClient) CreateAccountAccessRule(ctx context.Context, accountID string, accessRule cloudflare.AccessRule) (*cloudflare.AccessRuleResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.nextId++
+	accessRule.ID = fmt.Sprintf("rule-%d", c.nextId)
+	c.rules[accessRule.ID] = accessRule
+
+	return &cloudflare.AccessRuleResponse{Result: accessRule}, nil
+}
+
+func (c *fakeClient) DeleteAccountAccessRule(ctx context.Context, accountID, accessRuleID string) (*cloudflare.AccessRuleResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	rule, ok := c.rules[accessRuleID]
+	if !ok {
+		return nil, fakeNotFoundError()
+	}
+	delete(c.rules, accessRuleID)
+
+	return &cloudflare.AccessRuleResponse{Result: rule}, nil
+}
+
+func (c *fakeClient) SetWorkersSecret(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.SetWorkersSecretParams) (cloudflare.WorkersPutSecretResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.WorkersPutSecretResponse{}, c.err
+	}
+
+	c.workerSecrets[params.ScriptName+"/"+params.Secret.Name] = params.Secret.Text
+
+	return cloudflare.WorkersPutSecretResponse{}, nil
+}
+
+func (c *fakeClient) DeleteWorkersSecret(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.DeleteWorkersSecretParams) (cloudflare.Response, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.Response{}, c.err
+	}
+
+	key := params.ScriptName + "/" + params.SecretName
+	if _, ok := c.workerSecrets[key]; !ok {
+		return cloudflare.Response{}, fakeNotFoundError()
+	}
+	delete(c.workerSecrets, key)
+
+	return cloudflare.Response{}, nil
+}
+
+func (c *fakeClient) CreateAccountMember(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.CreateAccountMemberParams) (cloudflare.AccountMember, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return cloudflare.AccountMember{}, c.err
+	}
+
+	status := params.Status
+	if status == "" {
+		status = memberStatusPending
+	}
+
+	c.nextId++
+	member := cloudflare.AccountMember{
+		ID:     fmt.Sprintf("member-%d", c.nextId),
+		Status: status,
+		User:   cloudflare.AccountMemberUserDetails{Email: params.EmailAddress},
+	}
+	for _, role := range params.Roles {
+		member.Roles = append(member.Roles, cloudflare.AccountRole{ID: role})
+	}
+	c.members[member.ID] = member
+
+	return member, nil
+}
+
+func (c *fakeClient) DeleteAccountMember(ctx context.Context, accountID string, userID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	if _, ok := c.members[userID]; !ok {
+		return fakeNotFoundError()
+	}
+	delete(c.members, userID)
+
+	return nil
+}
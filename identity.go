@@ -0,0 +1,52 @@
+package cloudflare_secrets_engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const defaultEmailMetadataKey = "email"
+
+// requestEntity returns the identity entity of the client making the request.
+func (b *cloudflareBackend) requestEntity(req *logical.Request) (*logical.Entity, error) {
+	if req.EntityID == "" {
+		return nil, errors.New("request is not associated with an identity entity")
+	}
+
+	entity, err := b.System().EntityInfo(req.EntityID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving identity entity: %w", err)
+	}
+
+	if entity == nil {
+		return nil, fmt.Errorf("identity entity %q not found", req.EntityID)
+	}
+
+	return entity, nil
+}
+
+// entityEmail returns the email address stored in the entity metadata under key.
+func entityEmail(entity *logical.Entity, key string) (string, error) {
+	if key == "" {
+		key = defaultEmailMetadataKey
+	}
+
+	email, ok := entity.Metadata[key]
+	if !ok || email == "" {
+		return "", fmt.Errorf("identity entity %q has no %q metadata", entity.Name, key)
+	}
+
+	return email, nil
+}
+
+// entityMatchesMetadata checks that every bound key/value pair is present in the entity metadata.
+func entityMatchesMetadata(entity *logical.Entity, bound map[string]string) bool {
+	for k, v := range bound {
+		if entity.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// idleCheckInterval is how often the periodic function checks service tokens against max_idle. Each
+// check reads every token old enough to be idle from Cloudflare.
+const idleCheckInterval = 5 * time.Minute
+
+// revokeIdleCredentials deletes the service tokens of roles with max_idle that Cloudflare Access has
+// not seen used within max_idle, or at all within idle_grace_period of issuance. A plugin cannot
+// revoke Vault leases, so the token is deleted at Cloudflare and its lease is left to expire or be
+// revoked, which then succeeds although the token is already gone. The deleted token no longer
+// counts towards max_active or the active credentials gauge, and is dropped from the issued index.
+func (b *cloudflareBackend) revokeIdleCredentials(ctx context.Context, s logical.Storage) error {
+	replicationState := b.System().ReplicationState()
+	if replicationState.HasState(consts.ReplicationPerformanceSecondary) || replicationState.HasState(consts.ReplicationPerformanceStandby) {
+		return nil
+	}
+
+	ids, err := s.List(ctx, issuedStoragePrefix)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	roles := make(map[string]*cloudflareRoleEntry)
+	var client cloudflareClient
+	var notFoundErr *cloudflare.NotFoundError
+	var errs []error
+
+	for _, id := range ids {
+		issued, err := getIssuedCredential(ctx, s, id)
+		if err != nil {
+			return err
+		}
+
+		if issued == nil || issued.CredentialType != credentialTypeService {
+			continue
+		}
+
+		role, ok := roles[issued.Role]
+		if !ok {
+			role, err = b.getRole(ctx, s, issued.Role)
+			if err != nil {
+				return fmt.Errorf("error retrieving role: %w", err)
+			}
+			roles[issued.Role] = role
+		}
+
+		if role == nil || role.MaxIdle == 0 {
+			continue
+		}
+
+		gracePeriod := role.IdleGracePeriod
+		if gracePeriod == 0 {
+			gracePeriod = role.MaxIdle
+		}
+
+		// A token cannot have been idle for longer than it has existed.
+		if now.Sub(issued.IssuedAt) < minDuration(role.MaxIdle, gracePeriod) {
+			continue
+		}
+
+		if client == nil {
+			client, err = b.getClient(ctx, s)
+			if err != nil {
+				return err
+			}
+		}
+
+		lastSeen, err := serviceTokenLastSeen(ctx, client, issued.AccountID, issued.TokenID)
+		if errors.As(err, &notFoundErr) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reading service token %q: %w", issued.TokenID, err))
+			continue
+		}
+
+		if lastSeen == nil && now.Sub(issued.IssuedAt) < gracePeriod {
+			continue
+		}
+
+		if lastSeen != nil && now.Sub(*lastSeen) < role.MaxIdle {
+			continue
+		}
+
+		_, err = client.DeleteAccessServiceToken(ctx, issued.AccountID, issued.TokenID)
+		if err != nil && !errors.As(err, &notFoundErr) {
+			errs = append(errs, fmt.Errorf("error deleting idle service token %q: %w", issued.TokenID, err))
+			continue
+		}
+
+		if issued.ActiveID != "" {
+			if err := b.releaseActiveCredentials(ctx, s, issued.Role, issued.ActiveID, 1); err != nil {
+				return fmt.Errorf("error releasing max_active reservation of idle service token %q: %w", issued.TokenID, err)
+			}
+		}
+
+		if err := forgetIssuedCredentials(ctx, s, []string{issued.TokenID}); err != nil {
+			return err
+		}
+
+		b.adjustActiveCredentials(issued.Role, -1)
+
+		lastUsed := "never"
+		if lastSeen != nil {
+			lastUsed = lastSeen.UTC().Format(time.RFC3339)
+		}
+
+		b.Logger().Info("deleted idle service token", "role", issued.Role, "token_id", issued.TokenID,
+			"request_id", issued.RequestID, "last_used", lastUsed)
+		recordIdleRevocation(issued.Role)
+		b.sendEvent(ctx, eventCredentialIdleRevoke, map[string]interface{}{
+			"credential_type": issued.CredentialType,
+			"role":            issued.Role,
+			"account_id":      issued.AccountID,
+			"token_id":        issued.TokenID,
+			"request_id":      issued.RequestID,
+			"last_used":       lastUsed,
+		})
+	}
+
+	return errors.Join(errs...)
+}
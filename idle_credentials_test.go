@@ -0,0 +1,94 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleCredentials(t *testing.T) {
+	b, s, api := getTestBackendWithFakeAPI(t)
+	ctx := context.Background()
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type":   "service",
+		"account_id":        accountId,
+		"max_idle":          "1h",
+		"idle_grace_period": "2h",
+	})
+	require.NoError(t, err)
+
+	// issue returns a service token issued age ago.
+	issue := func(age time.Duration) *logical.Response {
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+
+		issued, err := getIssuedCredential(ctx, s, resp.Data["token_id"].(string))
+		require.NoError(t, err)
+		issued.IssuedAt = time.Now().Add(-age)
+		require.NoError(t, setIssuedCredential(ctx, s, issued))
+
+		return resp
+	}
+
+	recentlyUsed := issue(3 * time.Hour)
+	api.markSeen(recentlyUsed.Data["token_id"].(string), time.Now().Add(-time.Minute))
+
+	idle := issue(3 * time.Hour)
+	api.markSeen(idle.Data["token_id"].(string), time.Now().Add(-2*time.Hour))
+
+	neverUsed := issue(3 * time.Hour)
+	withinGrace := issue(90 * time.Minute)
+
+	require.NoError(t, b.revokeIdleCredentials(ctx, s))
+
+	require.Contains(t, api.serviceTokens, recentlyUsed.Data["token_id"])
+	require.NotContains(t, api.serviceTokens, idle.Data["token_id"])
+	require.NotContains(t, api.serviceTokens, neverUsed.Data["token_id"])
+	require.Contains(t, api.serviceTokens, withinGrace.Data["token_id"])
+
+	t.Run("Forget Deleted Tokens", func(t *testing.T) {
+		issued, err := getIssuedCredential(ctx, s, idle.Data["token_id"].(string))
+		require.NoError(t, err)
+		require.Nil(t, issued)
+
+		active, err := s.Get(ctx, activeStoragePrefix+"ci/"+idle.Secret.InternalData["active_id"].(string))
+		require.NoError(t, err)
+		require.Nil(t, active)
+
+		b.activeCountLock.Lock()
+		defer b.activeCountLock.Unlock()
+		require.Equal(t, 2, b.activeCounts["ci"])
+	})
+
+	t.Run("Renew Fails", func(t *testing.T) {
+		_, err := testSecretRequest(t, b, s, logical.RenewOperation, idle.Secret)
+		require.ErrorContains(t, err, "max_idle")
+	})
+
+	t.Run("Revoke Succeeds", func(t *testing.T) {
+		_, err := testSecretRequest(t, b, s, logical.RevokeOperation, neverUsed.Secret)
+		require.NoError(t, err)
+
+		issued, err := getIssuedCredential(ctx, s, neverUsed.Data["token_id"].(string))
+		require.NoError(t, err)
+		require.Nil(t, issued)
+
+		// The deleted token was already discounted.
+		b.activeCountLock.Lock()
+		defer b.activeCountLock.Unlock()
+		require.Equal(t, 2, b.activeCounts["ci"])
+	})
+
+	t.Run("Only Service Roles", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "zone-read", map[string]interface{}{
+			"credential_type": "api",
+			"policies":        `[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.*":"*"},"permission_groups":[{"id":"0000"}]}]`,
+			"max_idle":        "1h",
+		})
+		require.Error(t, err)
+	})
+}
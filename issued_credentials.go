@@ -0,0 +1,103 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const issuedStoragePrefix = "issued/"
+
+// issuedCredentialEntry records the lease metadata of a token issued by the engine, keyed by its
+// Cloudflare token ID, so that Cloudflare activity can be traced back to the Vault request that
+// created the token. Entries are removed when the lease is revoked, or the token is deleted for
+// exceeding its role's max_idle. ActiveID is the max_active reservation of the token's lease.
+type issuedCredentialEntry struct {
+	TokenID        string    `json:"token_id"`
+	CredentialType string    `json:"credential_type"`
+	Role           string    `json:"role"`
+	AccountID      string    `json:"account_id"`
+	TokenOwner     string    `json:"token_owner,omitempty"`
+	IssuedAt       time.Time `json:"issued_at"`
+	RequestID      string    `json:"request_id"`
+	DisplayName    string    `json:"display_name,omitempty"`
+	EntityID       string    `json:"entity_id,omitempty"`
+	ActiveID       string    `json:"active_id,omitempty"`
+}
+
+// leaseTokenIds returns the Cloudflare token IDs covered by a lease.
+func leaseTokenIds(secret *logical.Secret) []string {
+	if tokenId, ok := secret.InternalData["token_id"].(string); ok {
+		return []string{tokenId}
+	}
+
+	return internalDataStrings(secret.InternalData["token_ids"])
+}
+
+// recordIssuedCredentials indexes every token of a newly issued lease. The index only serves
+// lookups, so failing to write it is logged rather than failing the issuance.
+func (b *cloudflareBackend) recordIssuedCredentials(ctx context.Context, req *logical.Request, credentialType string, secret *logical.Secret) {
+	roleName, _ := secret.InternalData["role"].(string)
+	accountId, _ := secret.InternalData["account_id"].(string)
+	tokenOwner, _ := secret.InternalData["token_owner"].(string)
+	activeId, _ := secret.InternalData["active_id"].(string)
+	now := time.Now()
+
+	for _, tokenId := range leaseTokenIds(secret) {
+		err := setIssuedCredential(ctx, req.Storage, &issuedCredentialEntry{
+			TokenID:        tokenId,
+			CredentialType: credentialType,
+			Role:           roleName,
+			AccountID:      accountId,
+			TokenOwner:     tokenOwner,
+			IssuedAt:       now,
+			RequestID:      req.ID,
+			DisplayName:    req.DisplayName,
+			EntityID:       req.EntityID,
+			ActiveID:       activeId,
+		})
+		if err != nil {
+			b.Logger().Error("error recording issued credential", "token_id", tokenId, "error", err)
+		}
+	}
+}
+
+// forgetIssuedCredentials removes the index entries of revoked or deleted tokens.
+func forgetIssuedCredentials(ctx context.Context, s logical.Storage, tokenIds []string) error {
+	for _, tokenId := range tokenIds {
+		if err := s.Delete(ctx, issuedStoragePrefix+tokenId); err != nil {
+			return fmt.Errorf("error removing issued credential %q: %w", tokenId, err)
+		}
+	}
+
+	return nil
+}
+
+func getIssuedCredential(ctx context.Context, s logical.Storage, tokenId string) (*issuedCredentialEntry, error) {
+	entry, err := s.Get(ctx, issuedStoragePrefix+tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	var issued issuedCredentialEntry
+	if err := entry.DecodeJSON(&issued); err != nil {
+		return nil, err
+	}
+
+	return &issued, nil
+}
+
+func setIssuedCredential(ctx context.Context, s logical.Storage, issued *issuedCredentialEntry) error {
+	entry, err := logical.StorageEntryJSON(issuedStoragePrefix+issued.TokenID, issued)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
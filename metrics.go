@@ -0,0 +1,254 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+)
+
+var (
+	metricCredsIssue      = []string{"cloudflare", "creds", "issue"}
+	metricCredsRenew      = []string{"cloudflare", "creds", "renew"}
+	metricCredsRevoke     = []string{"cloudflare", "creds", "revoke"}
+	metricAPIRequest      = []string{"cloudflare", "api", "request"}
+	metricActiveCreds     = []string{"cloudflare", "creds", "active"}
+	metricOrphanedTokens  = []string{"cloudflare", "tokens", "orphaned"}
+	metricOrphansDetected = []string{"cloudflare", "tokens", "orphaned", "detected"}
+	metricIdleRevoked     = []string{"cloudflare", "creds", "idle_revoked"}
+)
+
+// recordCredentialOperation counts and times an issuance, renewal or revocation.
+func recordCredentialOperation(key []string, start time.Time, roleName string, credentialType string, outcome string) {
+	labels := []metrics.Label{
+		{Name: "role", Value: roleName},
+		{Name: "credential_type", Value: credentialType},
+		{Name: "outcome", Value: outcome},
+	}
+
+	metrics.MeasureSinceWithLabels(key, start, labels)
+	metrics.IncrCounterWithLabels(append(key[:len(key):len(key)], "count"), 1, labels)
+}
+
+// operationOutcome classifies the result of a request. Error responses, including those returned
+// with a status code, count as errors.
+func operationOutcome(resp *logical.Response, err error) string {
+	if err != nil || resp.IsError() {
+		return outcomeError
+	}
+
+	if resp != nil {
+		if status, ok := resp.Data[logical.HTTPStatusCode].(int); ok && status >= http.StatusBadRequest {
+			return outcomeError
+		}
+	}
+
+	return outcomeSuccess
+}
+
+// instrumentIssue wraps a path issuing credentials of credentialType with metrics and events.
+func (b *cloudflareBackend) instrumentIssue(credentialType string, f framework.OperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		start := time.Now()
+		resp, err := f(ctx, req, d)
+
+		roleName := d.Get("name").(string)
+		outcome := operationOutcome(resp, err)
+		recordCredentialOperation(metricCredsIssue, start, roleName, credentialType, outcome)
+
+		if outcome == outcomeSuccess && resp.Secret != nil {
+			resp.Secret.InternalData["issue_request_id"] = req.ID
+			b.adjustActiveCredentials(roleName, leaseCredentialCount(resp.Secret))
+		}
+
+		if outcome == outcomeSuccess {
+			metadata := secretEventMetadata(credentialType, resp.Secret)
+			metadata["role"] = roleName
+			metadata["request_id"] = req.ID
+			b.sendEvent(ctx, eventCredentialIssue, metadata)
+		}
+
+		return resp, err
+	}
+}
+
+// instrumentSecret wraps the renew and revoke callbacks of a secret holding credentials of
+// credentialType with metrics and events.
+func (b *cloudflareBackend) instrumentSecret(credentialType string, secret *framework.Secret) *framework.Secret {
+	instrument := func(key []string, eventType logical.EventType, f framework.OperationFunc) framework.OperationFunc {
+		if f == nil {
+			return nil
+		}
+
+		return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+			start := time.Now()
+
+			// Count before revoking, which forgets the issued tokens the count relies on.
+			active := 0
+			if eventType == eventCredentialRevoke {
+				active = activeLeaseCredentials(ctx, req.Storage, req.Secret)
+			}
+
+			resp, err := f(ctx, req, d)
+
+			roleName, _ := req.Secret.InternalData["role"].(string)
+			outcome := operationOutcome(resp, err)
+			recordCredentialOperation(key, start, roleName, credentialType, outcome)
+
+			if outcome == outcomeSuccess {
+				if eventType == eventCredentialRevoke {
+					b.adjustActiveCredentials(roleName, -active)
+				}
+				b.sendEvent(ctx, eventType, secretEventMetadata(credentialType, req.Secret))
+			}
+
+			return resp, err
+		}
+	}
+
+	secret.Renew = instrument(metricCredsRenew, eventCredentialRenew, secret.Renew)
+	secret.Revoke = instrument(metricCredsRevoke, eventCredentialRevoke, secret.Revoke)
+
+	return secret
+}
+
+// metricsTransport times every request sent to the Cloudflare API, labelled by method, endpoint
+// and status code.
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	status := outcomeError
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	metrics.MeasureSinceWithLabels(metricAPIRequest, start, []metrics.Label{
+		{Name: "method", Value: req.Method},
+		{Name: "endpoint", Value: apiEndpointLabel(req.URL.Path)},
+		{Name: "status", Value: status},
+	})
+
+	return resp, err
+}
+
+// apiEndpointLabel replaces the identifiers in an API path, such as account, zone and token IDs,
+// so that endpoints can be used as a metric label.
+func apiEndpointLabel(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if len(segment) >= 16 && strings.ContainsAny(segment, "0123456789") {
+			segments[i] = ":id"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func recordOrphanedToken() {
+	metrics.IncrCounter(metricOrphansDetected, 1)
+}
+
+func recordIdleRevocation(roleName string) {
+	metrics.IncrCounterWithLabels(metricIdleRevoked, 1, []metrics.Label{{Name: "role", Value: roleName}})
+}
+
+// leaseCredentialCount returns how many credentials a lease covers. Batch leases cover one per token.
+func leaseCredentialCount(secret *logical.Secret) int {
+	if count := len(leaseTokenIds(secret)); count > 0 {
+		return count
+	}
+	return 1
+}
+
+// activeLeaseCredentials returns how many credentials of a lease are still counted as active. Tokens
+// deleted for being idle were already discounted when their issued record was forgotten.
+func activeLeaseCredentials(ctx context.Context, s logical.Storage, secret *logical.Secret) int {
+	tokenIds := leaseTokenIds(secret)
+	if len(tokenIds) == 0 {
+		return 1
+	}
+
+	active := 0
+	for _, tokenId := range tokenIds {
+		if issued, err := getIssuedCredential(ctx, s, tokenId); err != nil || issued != nil {
+			active++
+		}
+	}
+	return active
+}
+
+// adjustActiveCredentials moves the count of a role's credentials covered by unrevoked leases by
+// delta. The counts are kept in memory, so revoking a lease issued before the plugin started, and
+// not seeded from storage, can never take a count below zero.
+func (b *cloudflareBackend) adjustActiveCredentials(roleName string, delta int) {
+	b.activeCountLock.Lock()
+	defer b.activeCountLock.Unlock()
+
+	count := b.activeCounts[roleName] + delta
+	if count <= 0 {
+		delete(b.activeCounts, roleName)
+		return
+	}
+	b.activeCounts[roleName] = count
+}
+
+// seedActiveCredentials counts the unrevoked service and API tokens in the issued index when the
+// plugin starts. Other credentials are counted from their issuance on.
+func (b *cloudflareBackend) seedActiveCredentials(ctx context.Context, s logical.Storage) error {
+	ids, err := s.List(ctx, issuedStoragePrefix)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, id := range ids {
+		issued, err := getIssuedCredential(ctx, s, id)
+		if err != nil {
+			return err
+		}
+
+		if issued != nil {
+			counts[issued.Role]++
+		}
+	}
+
+	b.activeCountLock.Lock()
+	defer b.activeCountLock.Unlock()
+
+	b.activeCounts = counts
+	return nil
+}
+
+// emitCredentialGauges reports the credentials of each role covered by unrevoked leases, and the
+// orphaned tokens that still exist at Cloudflare.
+func (b *cloudflareBackend) emitCredentialGauges(ctx context.Context, s logical.Storage, orphaned int) error {
+	metrics.SetGauge(metricOrphanedTokens, float32(orphaned))
+
+	roles, err := s.List(ctx, "role/")
+	if err != nil {
+		return err
+	}
+
+	b.activeCountLock.Lock()
+	defer b.activeCountLock.Unlock()
+
+	for _, roleName := range roles {
+		metrics.SetGaugeWithLabels(metricActiveCreds, float32(b.activeCounts[roleName]), []metrics.Label{{Name: "role", Value: roleName}})
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialMetrics(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	config := metrics.DefaultConfig("vault")
+	config.EnableHostname = false
+	config.EnableRuntimeMetrics = false
+	_, err := metrics.NewGlobal(config, sink)
+	require.NoError(t, err)
+
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err = testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+	})
+	require.NoError(t, err)
+
+	_, err = testServiceTokenRead(t, b, s, "ci", nil)
+	require.NoError(t, err)
+
+	client.err = fakeNotFoundError()
+	_, err = testServiceTokenRead(t, b, s, "ci", nil)
+	require.NoError(t, err)
+	client.err = nil
+
+	counters := sink.Data()[0].Counters
+	require.Equal(t, 1, counters["vault.cloudflare.creds.issue.count;role=ci;credential_type=service;outcome=success"].Count)
+	require.Equal(t, 1, counters["vault.cloudflare.creds.issue.count;role=ci;credential_type=service;outcome=error"].Count)
+}
+
+func TestActiveCredentialsGauge(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	config := metrics.DefaultConfig("vault")
+	config.EnableHostname = false
+	config.EnableRuntimeMetrics = false
+	_, err := metrics.NewGlobal(config, sink)
+	require.NoError(t, err)
+
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+	ctx := context.Background()
+
+	_, err = testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+		"max_batch":       5,
+	})
+	require.NoError(t, err)
+
+	single, err := testServiceTokenRead(t, b, s, "ci", nil)
+	require.NoError(t, err)
+
+	_, err = testServiceTokenRead(t, b, s, "ci", map[string]interface{}{"count": 3})
+	require.NoError(t, err)
+
+	require.NoError(t, b.emitCredentialGauges(ctx, s, 0))
+	require.Equal(t, float32(4), sink.Data()[0].Gauges["vault.cloudflare.creds.active;role=ci"].Value)
+
+	_, err = testSecretRequest(t, b, s, logical.RevokeOperation, single.Secret)
+	require.NoError(t, err)
+
+	require.NoError(t, b.emitCredentialGauges(ctx, s, 0))
+	require.Equal(t, float32(3), sink.Data()[0].Gauges["vault.cloudflare.creds.active;role=ci"].Value)
+
+	// A restarted plugin counts the tokens of unrevoked leases again.
+	restarted := backend(func(*cloudflareConfig) (cloudflareClient, error) {
+		return client, nil
+	})
+	backendConfig := logical.TestBackendConfig()
+	backendConfig.StorageView = s
+	backendConfig.Logger = hclog.NewNullLogger()
+	backendConfig.System = logical.TestSystemView()
+	require.NoError(t, restarted.Setup(ctx, backendConfig))
+	require.NoError(t, restarted.Initialize(ctx, &logical.InitializationRequest{Storage: s}))
+
+	require.NoError(t, restarted.emitCredentialGauges(ctx, s, 0))
+	require.Equal(t, float32(3), sink.Data()[0].Gauges["vault.cloudflare.creds.active;role=ci"].Value)
+}
+
+func TestAPIEndpointLabel(t *testing.T) {
+	require.Equal(t, "/client/v4/accounts/:id/access/service_tokens/:id/refresh",
+		apiEndpointLabel("/client/v4/accounts/023e105f4ecef8ad9ca31a8372d0c353/access/service_tokens/f174e90a-fafe-4643-bbbc-4a0ed4fc8415/refresh"))
+	require.Equal(t, "/client/v4/user/tokens", apiEndpointLabel("/client/v4/user/tokens"))
+}
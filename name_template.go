@@ -0,0 +1,94 @@
+package cloudflare_secrets_engine
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/helper/template"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// credentialNameMaxLength is the longest name Cloudflare accepts for API and service tokens.
+	credentialNameMaxLength = 120
+
+	defaultServiceTokenNameTemplate = `vault-account-{{uuid}}`
+	defaultAPITokenNameTemplate     = `vault-{{.RoleName}}-{{uuid}}`
+)
+
+// credentialNameData is the data available to role name templates.
+type credentialNameData struct {
+	RoleName      string
+	DisplayName   string
+	EntityName    string
+	MountAccessor string
+}
+
+func parseNameTemplate(nameTemplate string) (template.StringTemplate, error) {
+	return template.NewTemplate(template.Template(nameTemplate))
+}
+
+// validateNameTemplate checks that a name template parses and renders a non-empty name.
+func validateNameTemplate(nameTemplate string) error {
+	up, err := parseNameTemplate(nameTemplate)
+	if err != nil {
+		return err
+	}
+
+	name, err := up.Generate(credentialNameData{
+		RoleName:      "role",
+		DisplayName:   "token",
+		EntityName:    "entity",
+		MountAccessor: "cloudflare_12345678",
+	})
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		return fmt.Errorf("template renders an empty name")
+	}
+
+	return nil
+}
+
+// credentialName renders the name of a credential issued for req, followed by the caller's suffix if any,
+// truncated to the length Cloudflare accepts. The suffix is kept intact when truncating.
+func (b *cloudflareBackend) credentialName(req *logical.Request, roleName string, nameTemplate string, suffix string) (string, error) {
+	up, err := parseNameTemplate(nameTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error parsing name_template: %w", err)
+	}
+
+	data := credentialNameData{
+		RoleName:      roleName,
+		DisplayName:   req.DisplayName,
+		MountAccessor: req.MountAccessor,
+	}
+
+	if req.EntityID != "" {
+		if entity, err := b.requestEntity(req); err == nil {
+			data.EntityName = entity.Name
+		}
+	}
+
+	name, err := up.Generate(data)
+	if err != nil {
+		return "", fmt.Errorf("error generating credential name: %w", err)
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("error generating credential name: name_template rendered an empty name")
+	}
+
+	maxLength := credentialNameMaxLength
+	if suffix != "" {
+		suffix = "-" + suffix
+		maxLength -= len(suffix)
+	}
+
+	if runes := []rune(name); len(runes) > maxLength {
+		name = string(runes[:maxLength])
+	}
+
+	return name + suffix, nil
+}
@@ -0,0 +1,42 @@
+package cloudflare_secrets_engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialName(t *testing.T) {
+	b, _ := getTestBackend(t)
+
+	req := &logical.Request{
+		DisplayName:   "approle-ci",
+		MountAccessor: "cloudflare_12345678",
+	}
+
+	name, err := b.credentialName(req, "deploy", `{{.RoleName}}-{{.DisplayName}}-{{.MountAccessor}}`, "")
+	require.NoError(t, err)
+	require.Equal(t, "deploy-approle-ci-cloudflare_12345678", name)
+
+	name, err = b.credentialName(req, "deploy", defaultAPITokenNameTemplate, "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(name, "vault-deploy-"))
+
+	name, err = b.credentialName(req, strings.Repeat("a", 200), `{{.RoleName}}`, "")
+	require.NoError(t, err)
+	require.Len(t, name, credentialNameMaxLength)
+
+	name, err = b.credentialName(req, strings.Repeat("a", 200), `{{.RoleName}}`, "pipeline-42")
+	require.NoError(t, err)
+	require.Len(t, name, credentialNameMaxLength)
+	require.True(t, strings.HasSuffix(name, "a-pipeline-42"))
+}
+
+func TestValidateNameTemplate(t *testing.T) {
+	require.NoError(t, validateNameTemplate(`vault-{{.RoleName}}-{{unix_time}}-{{random 8}}`))
+	require.Error(t, validateNameTemplate(`vault-{{.RoleName`))
+	require.Error(t, validateNameTemplate(`{{.Unknown}}`))
+	require.Error(t, validateNameTemplate(`{{""}}`))
+}
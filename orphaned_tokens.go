@@ -0,0 +1,127 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const orphanStoragePrefix = "orphan/"
+
+// orphanedTokenEntry is a service token that was created at Cloudflare but is not covered by any
+// lease, because deleting it after a failed issuance also failed. It is reported in logs and metrics
+// for an operator to delete.
+type orphanedTokenEntry struct {
+	Role       string    `json:"role"`
+	AccountID  string    `json:"account_id"`
+	TokenID    string    `json:"token_id"`
+	Reason     string    `json:"reason"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// discardServiceToken deletes a service token that was created for a request that then failed. If
+// it cannot be deleted, it is recorded as orphaned.
+func (b *cloudflareBackend) discardServiceToken(ctx context.Context, s logical.Storage, tokenId string, roleName string, role *cloudflareRoleEntry, reason string) {
+	client, err := b.getClient(ctx, s)
+	if err == nil {
+		err = deleteToken(ctx, client, role.AccountID, tokenId)
+	}
+
+	if err == nil {
+		return
+	}
+
+	b.Logger().Error("error deleting service token "+reason, "token_id", tokenId, "error", err)
+	recordOrphanedToken()
+
+	orphan := &orphanedTokenEntry{
+		Role:       roleName,
+		AccountID:  role.AccountID,
+		TokenID:    tokenId,
+		Reason:     reason,
+		DetectedAt: time.Now(),
+	}
+
+	entry, err := logical.StorageEntryJSON(orphanStoragePrefix+tokenId, orphan)
+	if err == nil {
+		err = s.Put(ctx, entry)
+	}
+	if err != nil {
+		b.Logger().Error("error recording orphaned service token", "token_id", tokenId, "error", err)
+	}
+
+	b.sendEvent(ctx, eventOrphanRecorded, orphan.eventMetadata())
+}
+
+// eventMetadata returns the metadata of an event about the orphaned token.
+func (o *orphanedTokenEntry) eventMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"role":       o.Role,
+		"account_id": o.AccountID,
+		"token_id":   o.TokenID,
+		"reason":     o.Reason,
+	}
+}
+
+// countOrphanedTokens returns how many orphaned service tokens still exist at Cloudflare. They are
+// never deleted automatically: an operator deletes them, after which their records are dropped here.
+func (b *cloudflareBackend) countOrphanedTokens(ctx context.Context, s logical.Storage) (int, error) {
+	ids, err := s.List(ctx, orphanStoragePrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	replicationState := b.System().ReplicationState()
+	if replicationState.HasState(consts.ReplicationPerformanceSecondary) || replicationState.HasState(consts.ReplicationPerformanceStandby) {
+		return len(ids), nil
+	}
+
+	client, err := b.getClient(ctx, s)
+	if err != nil {
+		return len(ids), err
+	}
+
+	remaining := 0
+	var notFoundErr *cloudflare.NotFoundError
+
+	for _, id := range ids {
+		entry, err := s.Get(ctx, orphanStoragePrefix+id)
+		if err != nil {
+			return 0, err
+		}
+
+		if entry == nil {
+			continue
+		}
+
+		var orphan orphanedTokenEntry
+		if err := entry.DecodeJSON(&orphan); err != nil {
+			return 0, err
+		}
+
+		_, err = client.Raw(ctx, http.MethodGet, fmt.Sprintf("/accounts/%s/access/service_tokens/%s", orphan.AccountID, orphan.TokenID), nil, nil)
+		if err == nil || !errors.As(err, &notFoundErr) {
+			remaining++
+			continue
+		}
+
+		if err := s.Delete(ctx, orphanStoragePrefix+id); err != nil {
+			return 0, fmt.Errorf("error removing orphaned service token %q: %w", orphan.TokenID, err)
+		}
+
+		b.Logger().Info("orphaned service token was deleted", "token_id", orphan.TokenID)
+		b.sendEvent(ctx, eventOrphanDeleted, orphan.eventMetadata())
+	}
+
+	return remaining, nil
+}
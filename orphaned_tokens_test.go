@@ -0,0 +1,56 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanedTokens(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+	ctx := context.Background()
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+		"max_batch":       5,
+	})
+	require.NoError(t, err)
+
+	serviceErr := cloudflare.NewServiceError(&cloudflare.Error{StatusCode: 503})
+	client.deleteErr = &serviceErr
+	client.failCreateAfter = 2
+
+	_, err = testServiceTokenRead(t, b, s, "ci", map[string]interface{}{"count": 4})
+	require.NoError(t, err)
+	require.Len(t, client.tokens, 2)
+
+	orphans, err := s.List(ctx, orphanStoragePrefix)
+	require.NoError(t, err)
+	require.Len(t, orphans, 2)
+
+	// Orphaned tokens are counted, never deleted.
+	client.deleteErr = nil
+
+	remaining, err := b.countOrphanedTokens(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, 2, remaining)
+	require.Len(t, client.tokens, 2)
+
+	// Once an operator deletes a token, its record is dropped.
+	for id := range client.tokens {
+		delete(client.tokens, id)
+		break
+	}
+
+	remaining, err = b.countOrphanedTokens(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining)
+
+	orphans, err = s.List(ctx, orphanStoragePrefix)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+}
@@ -0,0 +1,176 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathAPIToken(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "api-token/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Lease TTL of the generated credential. It cannot exceed the role's max_ttl",
+			},
+			"name_suffix": {
+				Type:        framework.TypeString,
+				Description: "Suffix appended to the generated credential name, such as a pipeline identifier",
+			},
+			"zones": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Zone ids to restrict the generated token to. Requires a role with allow_narrowing, and every zone must be granted by the role",
+			},
+			"permission_groups": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Permission group ids to restrict the generated token to. Requires a role with allow_narrowing, and every permission group must be granted by the role",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeAPI, b.pathAPITokenRead),
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeAPI, b.pathAPITokenRead),
+			},
+		},
+		HelpSynopsis:    pathAPITokenHelpSyn,
+		HelpDescription: pathAPITokenHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathAPITokenRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeAPI {
+		return logical.ErrorResponse("role %q does not issue api tokens", roleName), nil
+	}
+
+	policies, err := apiTokenPolicies(roleEntry.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policies in cloudflare role: %w", err)
+	}
+
+	zones := d.Get("zones").([]string)
+	permissionGroups := d.Get("permission_groups").([]string)
+	if len(zones) > 0 || len(permissionGroups) > 0 {
+		if !roleEntry.AllowNarrowing {
+			return logical.ErrorResponse("role %q does not allow narrowing zones or permission groups", roleName), nil
+		}
+
+		policies, err = narrowAPITokenPolicies(policies, zones, permissionGroups)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	requestedTTL, err := callerTTL(time.Duration(d.Get("ttl").(int))*time.Second, roleEntry.MaxTTL, b.System())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	nameSuffix := d.Get("name_suffix").(string)
+	if err := validateNameSuffix(nameSuffix); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	condition, err := apiTokenCondition(req, roleEntry)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	ttl, _, err := framework.CalculateTTL(b.System(), requestedTTL, 0, 0, roleEntry.MaxTTL, 0, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	nameTemplate := roleEntry.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = defaultAPITokenNameTemplate
+	}
+
+	name, err := b.credentialName(req, roleName, nameTemplate, nameSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	activeId, err := b.reserveActiveCredentials(ctx, req.Storage, roleName, roleEntry.MaxActive, 1, ttl)
+	if errors.Is(err, errMaxActive) {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expiresOn := apiTokenExpiry(ttl)
+	token, err := createAPIToken(ctx, client, roleEntry.TokenOwner, roleEntry.AccountID, cloudflare.APIToken{
+		Name:      name,
+		Policies:  policies,
+		Condition: condition,
+		ExpiresOn: &expiresOn,
+	})
+	if err != nil {
+		if releaseErr := b.releaseActiveCredentials(ctx, req.Storage, roleName, activeId, 1); releaseErr != nil {
+			b.Logger().Error("error releasing max_active reservation", "role", roleName, "error", releaseErr)
+		}
+		return cloudflareErrorResponse(req, err)
+	}
+
+	resp := b.Secret(cloudflareAPITokenType).Response(token.toResponseData(), map[string]interface{}{
+		"token_id":    token.TokenID,
+		"token_owner": roleEntry.TokenOwner,
+		"account_id":  roleEntry.AccountID,
+		"role":        roleName,
+	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = roleEntry.MaxTTL
+	resp.Secret.InternalData["active_id"] = activeId
+
+	b.recordIssuedCredentials(ctx, req, credentialTypeAPI, resp.Secret)
+
+	return resp, nil
+}
+
+const pathAPITokenHelpSyn = `
+Generate a Cloudflare API token from a specific Vault role.
+`
+
+const pathAPITokenHelpDesc = `
+This path generates a Cloudflare API token with the policies of a particular
+role. Tokens are owned by the user of the configured credentials, or by the
+role's account when token_owner is "account", so they survive the user leaving.
+Tokens expire at Cloudflare when their lease does, and are deleted on revocation.
+Roles may restrict the addresses tokens can be used from, including binding each
+token to the address of the Vault client that requested it. Callers may request a
+shorter ttl, a name suffix and, if the role allows narrowing, a subset of the
+role's zones and permission groups.
+`
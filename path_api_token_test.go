@@ -0,0 +1,69 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func testAPITokenRead(t *testing.T, b *cloudflareBackend, s logical.Storage, name string) (*logical.Response, error) {
+	t.Helper()
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "api-token/" + name,
+		Storage:   s,
+	})
+}
+
+func TestAPITokenFakeAPI(t *testing.T) {
+	b, s, api := getTestBackendWithFakeAPI(t)
+
+	policies := fmt.Sprintf(`[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.%s":"*"},"permission_groups":[{"id":"%s"}]}]`, fakeZoneId, fakePermissionGroupId)
+
+	for _, owner := range []string{"user", "account"} {
+		t.Run("Issue, Renew And Revoke "+owner+" Token", func(t *testing.T) {
+			_, err := testServiceRoleCreate(t, b, s, "zone-read-"+owner, map[string]interface{}{
+				"credential_type": "api",
+				"account_id":      accountId,
+				"policies":        policies,
+				"token_owner":     owner,
+			})
+			require.NoError(t, err)
+
+			resp, err := testAPITokenRead(t, b, s, "zone-read-"+owner)
+			require.NoError(t, err)
+			require.NotEmpty(t, resp.Data["token"])
+
+			key := "user/" + resp.Data["token_id"].(string)
+			if owner == "account" {
+				key = accountId + "/" + resp.Data["token_id"].(string)
+			}
+			require.Contains(t, api.apiTokens, key)
+
+			_, err = testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+			require.NoError(t, err)
+			require.Equal(t, "value-"+resp.Data["token_id"].(string)[len("api-token-"):], api.apiTokens[key].Value)
+
+			_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+			require.NoError(t, err)
+			require.NotContains(t, api.apiTokens, key)
+		})
+	}
+
+	t.Run("Report Invalid Policies", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "unknown-group", map[string]interface{}{
+			"credential_type": "api",
+			"policies":        `[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.*":"*"},"permission_groups":[{"id":"0000"}]}]`,
+		})
+		require.NoError(t, err)
+
+		resp, err := testAPITokenRead(t, b, s, "unknown-group")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.Data[logical.HTTPStatusCode])
+		require.Contains(t, resp.Data[logical.HTTPRawBody], "cloudflare error codes: 1001")
+	})
+}
@@ -0,0 +1,121 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathClientCert(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "client-cert/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+			"csr": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded certificate signing request. If omitted, Vault generates the key pair",
+			},
+			"common_name": {
+				Type:        framework.TypeString,
+				Description: "Common name of the generated certificate, such as a device identifier. Required when csr is omitted",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeClientCert, b.pathClientCertWrite),
+			},
+		},
+		HelpSynopsis:    pathClientCertHelpSyn,
+		HelpDescription: pathClientCertHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathClientCertWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeClientCert {
+		return logical.ErrorResponse("role %q does not issue client certificates", roleName), nil
+	}
+
+	var keyPair *certificateKeyPair
+	csr := d.Get("csr").(string)
+	if csr != "" {
+		parsed, err := parseCSR(csr)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if csrKeyType(parsed) != roleEntry.KeyType {
+			return logical.ErrorResponse("csr key type does not match role key type %q", roleEntry.KeyType), nil
+		}
+	} else {
+		commonName := d.Get("common_name").(string)
+		if commonName == "" {
+			return logical.ErrorResponse("missing common_name"), nil
+		}
+		keyPair, err = generateKeyAndCSR(roleEntry.KeyType, commonName, nil)
+		if err != nil {
+			return nil, err
+		}
+		csr = keyPair.CSR
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := createClientCertificate(ctx, client, roleEntry.ZoneID, csr, roleEntry.ValidityDays)
+	if err != nil {
+		return cloudflareErrorResponse(req, err)
+	}
+
+	if keyPair != nil {
+		cert.PrivateKey = keyPair.PrivateKey
+		cert.PrivateKeyType = keyPair.PrivateKeyType
+	}
+
+	resp := b.Secret(cloudflareClientCertificateType).Response(cert.toResponseData(), map[string]interface{}{
+		"certificate_id": cert.CertificateID,
+		"zone_id":        roleEntry.ZoneID,
+		"expiration":     cert.Expiration.Format(time.RFC3339),
+		"role":           roleName,
+	})
+
+	if lifetime := time.Until(cert.Expiration); lifetime > 0 {
+		resp.Secret.MaxTTL = lifetime
+	}
+
+	return resp, nil
+}
+
+const pathClientCertHelpSyn = `
+Issue a Cloudflare API Shield client certificate from a specific Vault role.
+`
+
+const pathClientCertHelpDesc = `
+This path issues a client certificate from the Cloudflare managed CA of the
+role's zone, for use with API Shield mTLS. A certificate signing request may be
+supplied, otherwise Vault generates the key pair and returns the private key
+alongside the certificate. The certificate is revoked when the lease ends.
+`
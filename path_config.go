@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -16,6 +18,11 @@ const (
 
 type cloudflareConfig struct {
 	APIToken string `json:"api_token"`
+	BaseURL  string `json:"base_url,omitempty"`
+
+	RateLimit         int           `json:"rate_limit,omitempty"`
+	RateLimitInterval time.Duration `json:"rate_limit_interval,omitempty"`
+	RateLimitBurst    int           `json:"burst,omitempty"`
 }
 
 func pathConfig(b *cloudflareBackend) *framework.Path {
@@ -31,6 +38,23 @@ func pathConfig(b *cloudflareBackend) *framework.Path {
 					Sensitive: true,
 				},
 			},
+			"base_url": {
+				Type:        framework.TypeString,
+				Description: "Base URL of the Cloudflare v4 API, such as a proxy or a fake for testing. Defaults to https://api.cloudflare.com/client/v4",
+			},
+			"rate_limit": {
+				Type:        framework.TypeInt,
+				Description: "The number of credentials all roles of the mount together can issue per rate_limit_interval. Defaults to 0, no limit",
+			},
+			"rate_limit_interval": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The interval rate_limit applies to",
+				Default:     int(defaultRateLimitInterval.Seconds()),
+			},
+			"burst": {
+				Type:        framework.TypeInt,
+				Description: "The number of credentials the mount can issue at once before rate_limit applies. Defaults to rate_limit",
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
@@ -72,11 +96,23 @@ func (b *cloudflareBackend) pathConfigRead(ctx context.Context, req *logical.Req
 
 	mask := strings.Repeat("x", len(apiToken)-4)
 
-	return &logical.Response{
+	resp := &logical.Response{
 		Data: map[string]interface{}{
 			"api_token": mask + lastFour,
 		},
-	}, nil
+	}
+
+	if config.BaseURL != "" {
+		resp.Data["base_url"] = config.BaseURL
+	}
+
+	if config.RateLimit > 0 {
+		resp.Data["rate_limit"] = config.RateLimit
+		resp.Data["rate_limit_interval"] = int64(config.RateLimitInterval.Seconds())
+		resp.Data["burst"] = config.RateLimitBurst
+	}
+
+	return resp, nil
 }
 
 func (b *cloudflareBackend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -100,6 +136,34 @@ func (b *cloudflareBackend) pathConfigWrite(ctx context.Context, req *logical.Re
 		return nil, fmt.Errorf("missing api_token in configuration")
 	}
 
+	if baseURL, ok := data.GetOk("base_url"); ok {
+		config.BaseURL = strings.TrimSuffix(baseURL.(string), "/")
+	}
+
+	if config.BaseURL != "" {
+		if u, err := url.Parse(config.BaseURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("base_url must be an absolute https URL")
+		}
+	}
+
+	if rateLimit, ok := data.GetOk("rate_limit"); ok {
+		config.RateLimit = rateLimit.(int)
+	}
+
+	if rateLimitInterval, ok := data.GetOk("rate_limit_interval"); ok {
+		config.RateLimitInterval = time.Duration(rateLimitInterval.(int)) * time.Second
+	} else if config.RateLimitInterval == 0 {
+		config.RateLimitInterval = time.Duration(data.Get("rate_limit_interval").(int)) * time.Second
+	}
+
+	if burst, ok := data.GetOk("burst"); ok {
+		config.RateLimitBurst = burst.(int)
+	}
+
+	if config.RateLimit < 0 || config.RateLimitBurst < 0 || config.RateLimitInterval <= 0 {
+		return nil, fmt.Errorf("rate_limit, rate_limit_interval and burst must be positive")
+	}
+
 	entry, err := logical.StorageEntryJSON(configStoragePath, config)
 	if err != nil {
 		return nil, err
@@ -146,4 +210,6 @@ const pathConfigHelpSynopsis = `Configure the Cloudflare backend.`
 
 const pathConfigHelpDescription = `
 The Cloudflare secret backend requires credentials for managing tokens.
+An optional mount-wide rate limit caps credential issuance across all roles.
+The API base URL can be changed to route requests through a proxy.
 `
@@ -45,6 +45,36 @@ func TestConfig(t *testing.T) {
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("Test Base URL", func(t *testing.T) {
+		err := testConfigCreate(t, b, reqStorage, map[string]interface{}{
+			"api_token": apiToken,
+			"base_url":  "api.cloudflare.com/client/v4",
+		})
+
+		assert.Error(t, err)
+
+		err = testConfigCreate(t, b, reqStorage, map[string]interface{}{
+			"api_token": apiToken,
+			"base_url":  "http://127.0.0.1:8080/client/v4",
+		})
+
+		assert.Error(t, err)
+
+		err = testConfigCreate(t, b, reqStorage, map[string]interface{}{
+			"api_token": apiToken,
+			"base_url":  "https://127.0.0.1:8080/client/v4/",
+		})
+
+		assert.NoError(t, err)
+
+		err = testConfigRead(t, b, reqStorage, map[string]interface{}{
+			"api_token": "xxxxxxxxxxoken",
+			"base_url":  "https://127.0.0.1:8080/client/v4",
+		})
+
+		assert.NoError(t, err)
+	})
 }
 
 func testConfigDelete(t *testing.T, b logical.Backend, s logical.Storage) error {
@@ -0,0 +1,220 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// activityPageSize is the largest page of audit log entries Cloudflare returns.
+	activityPageSize = 1000
+	// activityMaxPages bounds the audit log pages read for a single activity request.
+	activityMaxPages = 5
+	// activityClockSkew widens the search before issued_at, which is recorded after Cloudflare
+	// created the token, possibly by a clock running slightly behind Cloudflare's.
+	activityClockSkew = time.Minute
+)
+
+func pathCredsActivity(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("token_id") + "/activity",
+		Fields: map[string]*framework.FieldSchema{
+			"token_id": {
+				Type:        framework.TypeString,
+				Description: "Cloudflare ID of a service token or API token issued by this engine",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathCredsActivityRead,
+			},
+		},
+		HelpSynopsis:    pathCredsActivityHelpSyn,
+		HelpDescription: pathCredsActivityHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathCredsActivityRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenId := d.Get("token_id").(string)
+
+	issued, err := getIssuedCredential(ctx, req.Storage, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	if issued == nil {
+		return logical.ErrorResponse("no unrevoked credential with token id %q was issued by this engine", tokenId), nil
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	events, truncated, err := tokenAuditLogs(ctx, client, issued)
+	if errors.Is(err, errNoAuditLog) {
+		return logical.ErrorResponse("token %q has no account whose audit log can be searched", tokenId), nil
+	}
+	if err != nil {
+		return cloudflareErrorResponse(req, fmt.Errorf("error reading audit logs: %w", err))
+	}
+
+	var lastUsed *time.Time
+	switch {
+	case issued.CredentialType == credentialTypeService:
+		lastUsed, err = serviceTokenLastSeen(ctx, client, issued.AccountID, tokenId)
+		if err != nil {
+			return cloudflareErrorResponse(req, fmt.Errorf("error reading service token: %w", err))
+		}
+	default:
+		// Without a last seen time, the latest action performed by the token is the best estimate.
+		for _, event := range events {
+			if event["relation"] == "actor" {
+				when := event["when"].(time.Time)
+				lastUsed = &when
+				break
+			}
+		}
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"token_id":        issued.TokenID,
+			"credential_type": issued.CredentialType,
+			"role":            issued.Role,
+			"account_id":      issued.AccountID,
+			"issued_at":       issued.IssuedAt,
+			"request_id":      issued.RequestID,
+			"display_name":    issued.DisplayName,
+			"entity_id":       issued.EntityID,
+			"events":          events,
+			"last_used":       lastUsed,
+		},
+	}
+
+	if truncated {
+		resp.AddWarning(fmt.Sprintf("only the latest %d audit log entries were searched", activityPageSize*activityMaxPages))
+	}
+
+	return resp, nil
+}
+
+// errNoAuditLog is returned for tokens with neither an account nor a user owner.
+var errNoAuditLog = errors.New("no audit log to search")
+
+// tokenAuditLogs returns the audit log entries since the token was issued that were performed by,
+// or on, the token, most recent first. API tokens owned by the configured user are searched in the
+// user's audit log, as their roles need not name an account, and other tokens in their account's.
+// It reports whether older entries were left unsearched.
+func tokenAuditLogs(ctx context.Context, c cloudflareClient, issued *issuedCredentialEntry) ([]map[string]interface{}, bool, error) {
+	userLog := issued.CredentialType == credentialTypeAPI && issued.TokenOwner == tokenOwnerUser
+	if !userLog && issued.AccountID == "" {
+		return nil, false, errNoAuditLog
+	}
+
+	events := []map[string]interface{}{}
+
+	for page := 1; page <= activityMaxPages; page++ {
+		filter := cloudflare.AuditLogFilter{
+			Since:     issued.IssuedAt.Add(-activityClockSkew).UTC().Format(time.RFC3339),
+			Direction: "desc",
+			PerPage:   activityPageSize,
+			Page:      page,
+		}
+
+		var response cloudflare.AuditLogResponse
+		var err error
+		if userLog {
+			response, err = c.GetUserAuditLogs(ctx, filter)
+		} else {
+			response, err = c.GetOrganizationAuditLogs(ctx, issued.AccountID, filter)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, log := range response.Result {
+			relation := auditLogRelation(log, issued.TokenID)
+			if relation == "" {
+				continue
+			}
+
+			events = append(events, map[string]interface{}{
+				"id":            log.ID,
+				"when":          log.When,
+				"relation":      relation,
+				"action":        log.Action.Type,
+				"result":        log.Action.Result,
+				"actor_id":      log.Actor.ID,
+				"actor_email":   log.Actor.Email,
+				"actor_ip":      log.Actor.IP,
+				"actor_type":    log.Actor.Type,
+				"resource_id":   log.Resource.ID,
+				"resource_type": log.Resource.Type,
+			})
+		}
+
+		if page >= response.TotalPages {
+			return events, false, nil
+		}
+	}
+
+	return events, true, nil
+}
+
+// auditLogRelation returns whether a token was the actor or the resource of an audit log entry,
+// or an empty string if the entry does not involve it.
+func auditLogRelation(log cloudflare.AuditLog, tokenId string) string {
+	switch tokenId {
+	case log.Actor.ID:
+		return "actor"
+	case log.Resource.ID:
+		return "resource"
+	default:
+		return ""
+	}
+}
+
+// serviceTokenLastSeen returns when Cloudflare Access last saw a service token used, or nil if it
+// never has. cloudflare-go does not expose the field, so the token is read directly.
+func serviceTokenLastSeen(ctx context.Context, c cloudflareClient, accountId string, tokenId string) (*time.Time, error) {
+	raw, err := c.Raw(ctx, http.MethodGet, fmt.Sprintf("/accounts/%s/access/service_tokens/%s", accountId, tokenId), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var token struct {
+		LastSeenAt *time.Time `json:"last_seen_at"`
+	}
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return nil, err
+	}
+
+	return token.LastSeenAt, nil
+}
+
+const pathCredsActivityHelpSyn = `
+Read the Cloudflare activity of a token issued by this engine.
+`
+
+const pathCredsActivityHelpDesc = `
+This path correlates a service token or API token issued by this engine with
+the Cloudflare audit log: the user audit log for API tokens owned by the
+configured user, and the account audit log otherwise. It returns the metadata of the Vault request
+that issued the token, such as its role, request ID and requester, with the
+audit log entries since issuance that were performed by, or on, the token.
+
+last_used is when Cloudflare Access last saw a service token. For API tokens it
+is the latest audit log entry performed by the token, if any. Tokens are only
+known to this path from issuance until their lease is revoked, or until a
+service token is deleted for exceeding its role's max_idle.
+`
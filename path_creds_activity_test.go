@@ -0,0 +1,135 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredsActivity(t *testing.T) {
+	b, s, api := getTestBackendWithFakeAPI(t)
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+	})
+	require.NoError(t, err)
+
+	policies := fmt.Sprintf(`[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.%s":"*"},"permission_groups":[{"id":"%s"}]}]`, fakeZoneId, fakePermissionGroupId)
+	_, err = testServiceRoleCreate(t, b, s, "zone-read", map[string]interface{}{
+		"credential_type": "api",
+		"account_id":      accountId,
+		"policies":        policies,
+	})
+	require.NoError(t, err)
+
+	t.Run("Service Token", func(t *testing.T) {
+		token, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+		tokenId := token.Data["token_id"].(string)
+
+		resp, err := testCredsActivityRead(t, b, s, tokenId)
+		require.NoError(t, err)
+		require.Equal(t, "ci", resp.Data["role"])
+		require.Equal(t, credentialTypeService, resp.Data["credential_type"])
+		require.Equal(t, accountId, resp.Data["account_id"])
+		require.Nil(t, resp.Data["last_used"])
+
+		events := resp.Data["events"].([]map[string]interface{})
+		require.Len(t, events, 1)
+		require.Equal(t, "create", events[0]["action"])
+		require.Equal(t, "resource", events[0]["relation"])
+
+		lastSeen := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		api.markSeen(tokenId, lastSeen)
+
+		resp, err = testCredsActivityRead(t, b, s, tokenId)
+		require.NoError(t, err)
+		require.True(t, lastSeen.Equal(*resp.Data["last_used"].(*time.Time)))
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, token.Secret)
+		require.NoError(t, err)
+
+		resp, err = testCredsActivityRead(t, b, s, tokenId)
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+
+	t.Run("API Token", func(t *testing.T) {
+		token, err := testAPITokenRead(t, b, s, "zone-read")
+		require.NoError(t, err)
+		tokenId := token.Data["token_id"].(string)
+
+		used := time.Now().UTC().Truncate(time.Second)
+		api.addAuditLog(cloudflare.AuditLog{
+			ID:       "purge",
+			When:     used,
+			Action:   cloudflare.AuditLogAction{Type: "purge", Result: true},
+			Actor:    cloudflare.AuditLogActor{ID: tokenId, Type: "token"},
+			Resource: cloudflare.AuditLogResource{ID: fakeZoneId, Type: "zone"},
+		})
+		api.addAuditLog(cloudflare.AuditLog{
+			ID:       "unrelated",
+			When:     used,
+			Action:   cloudflare.AuditLogAction{Type: "purge", Result: true},
+			Actor:    cloudflare.AuditLogActor{ID: "someone-else", Type: "user"},
+			Resource: cloudflare.AuditLogResource{ID: fakeZoneId, Type: "zone"},
+		})
+
+		resp, err := testCredsActivityRead(t, b, s, tokenId)
+		require.NoError(t, err)
+		require.Equal(t, credentialTypeAPI, resp.Data["credential_type"])
+
+		events := resp.Data["events"].([]map[string]interface{})
+		require.Len(t, events, 2)
+		require.Equal(t, "purge", events[0]["id"])
+		require.Equal(t, "actor", events[0]["relation"])
+		require.Equal(t, "create", events[1]["action"])
+		require.True(t, used.Equal(*resp.Data["last_used"].(*time.Time)))
+	})
+
+	t.Run("User Owned API Token Without An Account", func(t *testing.T) {
+		userPolicies := fmt.Sprintf(`[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.%s":"*"},"permission_groups":[{"id":"%s"}]}]`, fakeZoneId, fakePermissionGroupId)
+		_, err := testServiceRoleCreate(t, b, s, "user-zone-read", map[string]interface{}{
+			"credential_type": "api",
+			"token_owner":     "user",
+			"policies":        userPolicies,
+		})
+		require.NoError(t, err)
+
+		token, err := testAPITokenRead(t, b, s, "user-zone-read")
+		require.NoError(t, err)
+		require.False(t, token.IsError(), token.Error())
+		tokenId := token.Data["token_id"].(string)
+
+		resp, err := testCredsActivityRead(t, b, s, tokenId)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), resp.Error())
+		require.Empty(t, resp.Data["account_id"])
+
+		events := resp.Data["events"].([]map[string]interface{})
+		require.Len(t, events, 1)
+		require.Equal(t, "create", events[0]["action"])
+	})
+
+	t.Run("Unknown Token", func(t *testing.T) {
+		resp, err := testCredsActivityRead(t, b, s, "unknown")
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func testCredsActivityRead(t *testing.T, b logical.Backend, s logical.Storage, tokenId string) (*logical.Response, error) {
+	t.Helper()
+
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/" + tokenId + "/activity",
+		Storage:   s,
+	})
+}
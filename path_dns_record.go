@@ -0,0 +1,134 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathDNSRecord(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "dns-record/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+			"record_name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Fully qualified name of the DNS record, such as _acme-challenge.www.example.com",
+				Required:    true,
+			},
+			"record_type": {
+				Type:        framework.TypeString,
+				Description: "Type of the DNS record. Defaults to the role's only allowed record type",
+			},
+			"content": {
+				Type:        framework.TypeString,
+				Description: "Content of the DNS record",
+				Required:    true,
+			},
+			"record_ttl": {
+				Type:        framework.TypeInt,
+				Description: "TTL of the DNS record in seconds, 1 means automatic",
+				Default:     1,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeDNSRecord, b.pathDNSRecordWrite),
+			},
+		},
+		HelpSynopsis:    pathDNSRecordHelpSyn,
+		HelpDescription: pathDNSRecordHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathDNSRecordWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeDNSRecord {
+		return logical.ErrorResponse("role %q does not issue dns records", roleName), nil
+	}
+
+	recordName := strings.TrimSuffix(d.Get("record_name").(string), ".")
+	if recordName == "" {
+		return logical.ErrorResponse("missing record_name"), nil
+	}
+
+	if !hostnameAllowed(roleEntry.AllowedRecordNames, recordName) {
+		return logical.ErrorResponse("record_name %q is not allowed by role %q", recordName, roleName), nil
+	}
+
+	recordType := strings.ToUpper(d.Get("record_type").(string))
+	if recordType == "" {
+		if len(roleEntry.AllowedRecordTypes) != 1 {
+			return logical.ErrorResponse("missing record_type"), nil
+		}
+		recordType = roleEntry.AllowedRecordTypes[0]
+	}
+
+	if !strutil.StrListContains(roleEntry.AllowedRecordTypes, recordType) {
+		return logical.ErrorResponse("record_type %q is not allowed by role %q", recordType, roleName), nil
+	}
+
+	content := d.Get("content").(string)
+	if content == "" {
+		return logical.ErrorResponse("missing content"), nil
+	}
+
+	if recordType == "CNAME" {
+		content = strings.TrimSuffix(content, ".")
+		if !hostnameAllowed(roleEntry.AllowedContent, content) {
+			return logical.ErrorResponse("content %q is not allowed by role %q", content, roleName), nil
+		}
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := createDNSRecord(ctx, client, roleEntry.ZoneID, recordType, recordName, content, d.Get("record_ttl").(int))
+	if err != nil {
+		return cloudflareErrorResponse(req, err)
+	}
+
+	resp := b.Secret(cloudflareDNSRecordType).Response(record.toResponseData(), map[string]interface{}{
+		"record_id": record.RecordID,
+		"zone_id":   record.ZoneID,
+		"role":      roleName,
+	})
+
+	return resp, nil
+}
+
+const pathDNSRecordHelpSyn = `
+Create a lease-bound Cloudflare DNS record from a specific Vault role.
+`
+
+const pathDNSRecordHelpDesc = `
+This path creates a DNS record, such as an ACME DNS-01 challenge, in the role's
+zone. The record type and name must be allowed by the role, and the target of
+a CNAME record must match the role's allowed_content. TXT content is not
+restricted. The record is deleted when the lease ends.
+`
@@ -0,0 +1,111 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathFirewallAllow(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "firewall-allow/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+			"ip": {
+				Type:        framework.TypeString,
+				Description: "IP address or CIDR block to add. Defaults to the requester's address. Must fall within the role's allowed_cidrs",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeFirewall, b.pathFirewallAllowWrite),
+			},
+		},
+		HelpSynopsis:    pathFirewallAllowHelpSyn,
+		HelpDescription: pathFirewallAllowHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathFirewallAllowWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeFirewall {
+		return logical.ErrorResponse("role %q does not issue firewall allow entries", roleName), nil
+	}
+
+	var prefix netip.Prefix
+	if ip := d.Get("ip").(string); ip != "" {
+		if len(roleEntry.AllowedCIDRs) == 0 {
+			return logical.ErrorResponse("role %q does not allow requesting an ip", roleName), nil
+		}
+
+		prefix, err = parsePrefix(ip)
+		if err != nil {
+			return logical.ErrorResponse("invalid ip %q: %s", ip, err), nil
+		}
+
+		if !prefixWithin(prefix, roleEntry.AllowedCIDRs) {
+			return logical.ErrorResponse("ip %q is not within the allowed_cidrs of role %q", ip, roleName), nil
+		}
+	} else {
+		prefix, err = requesterPrefix(req, roleEntry.MinPrefixLengthIPv6)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if err := checkPrefixLength(roleEntry, prefix); err != nil {
+		return logical.ErrorResponse("%s %q", err, roleName), nil
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	allow, err := createFirewallAllow(ctx, client, roleEntry, prefix, fmt.Sprintf("vault role %s", roleName))
+	if err != nil {
+		return cloudflareErrorResponse(req, err)
+	}
+
+	resp := b.Secret(cloudflareFirewallAllowType).Response(allow.toResponseData(), map[string]interface{}{
+		"entry_id":   allow.EntryID,
+		"account_id": roleEntry.AccountID,
+		"zone_id":    roleEntry.ZoneID,
+		"list_id":    allow.ListID,
+		"role":       roleName,
+	})
+
+	return resp, nil
+}
+
+const pathFirewallAllowHelpSyn = `
+Temporarily allowlist the requester's IP address from a specific Vault role.
+`
+
+const pathFirewallAllowHelpDesc = `
+This path adds the requester's IP address, or an explicitly requested address
+within the role's allowed CIDR blocks, to the role's IP list or as an IP Access
+Rule on the role's zone or account. The entry is removed when the lease ends.
+`
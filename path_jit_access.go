@@ -0,0 +1,97 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathJITAccess(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "jit-access/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeJITAccess, b.pathJITAccessWrite),
+			},
+		},
+		HelpSynopsis:    pathJITAccessHelpSyn,
+		HelpDescription: pathJITAccessHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathJITAccessWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeJITAccess {
+		return logical.ErrorResponse("role %q does not issue access grants", roleName), nil
+	}
+
+	entity, err := b.requestEntity(req)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if !entityMatchesMetadata(entity, roleEntry.BoundEntityMetadata) {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	email, err := entityEmail(entity, roleEntry.EmailMetadataKey)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	grant, err := b.createAccessGrant(ctx, req.Storage, client, roleEntry, email)
+	if err != nil {
+		return cloudflareErrorResponse(req, err)
+	}
+
+	resp := b.Secret(cloudflareAccessGrantType).Response(grant.toResponseData(), map[string]interface{}{
+		"grant_id":       grant.GrantID,
+		"email":          grant.Email,
+		"account_id":     roleEntry.AccountID,
+		"application_id": grant.ApplicationID,
+		"policy_id":      grant.PolicyID,
+		"role":           roleName,
+	})
+
+	return resp, nil
+}
+
+const pathJITAccessHelpSyn = `
+Grant the requesting identity time-boxed access to a Cloudflare Access application.
+`
+
+const pathJITAccessHelpDesc = `
+This path adds the requesting identity entity's email address as an include
+rule on the role's dedicated Access policy. The entity must match the role's
+bound metadata. The rule is removed when the last lease for the email ends,
+unless it was already on the policy before Vault first granted it.
+`
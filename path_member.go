@@ -0,0 +1,108 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathMember(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "member/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+			"email": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Email address to add as a member. Defaults to the email address of the requesting identity entity",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeMember, b.pathMemberWrite),
+			},
+		},
+		HelpSynopsis:    pathMemberHelpSyn,
+		HelpDescription: pathMemberHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathMemberWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeMember {
+		return logical.ErrorResponse("role %q does not issue account memberships", roleName), nil
+	}
+
+	email := d.Get("email").(string)
+	if email != "" {
+		if len(roleEntry.AllowedEmails) == 0 {
+			return logical.ErrorResponse("role %q does not allow requesting an email address", roleName), nil
+		}
+	} else {
+		entity, err := b.requestEntity(req)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		email, err = entityEmail(entity, roleEntry.EmailMetadataKey)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		email = strings.ToLower(email)
+	}
+
+	if len(roleEntry.AllowedEmails) > 0 && !strutil.StrListContainsGlob(roleEntry.AllowedEmails, email) {
+		return logical.ErrorResponse("email %q is not allowed by role %q", email, roleName), nil
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := createAccountMember(ctx, client, roleEntry, email)
+	if err != nil {
+		return cloudflareErrorResponse(req, err)
+	}
+
+	resp := b.Secret(cloudflareAccountMemberType).Response(member.toResponseData(), map[string]interface{}{
+		"member_id":  member.MemberID,
+		"account_id": roleEntry.AccountID,
+		"role":       roleName,
+	})
+
+	return resp, nil
+}
+
+const pathMemberHelpSyn = `
+Add a temporary Cloudflare account member from a specific Vault role.
+`
+
+const pathMemberHelpDesc = `
+This path adds an email address as a member of the role's account with the
+role's account roles. The email address is taken from the requesting identity
+entity, or from the request when the role has an allow-list of email addresses.
+The membership is removed when the lease ends.
+`
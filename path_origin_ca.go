@@ -0,0 +1,154 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathOriginCA(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "origin-ca/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role",
+				Required:    true,
+			},
+			"csr": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded certificate signing request. If omitted, Vault generates the key pair",
+			},
+			"hostnames": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Hostnames to include in the certificate. Defaults to the hostnames in the csr, or the role's allowed hostnames",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.instrumentIssue(credentialTypeOriginCA, b.pathOriginCAWrite),
+			},
+		},
+		HelpSynopsis:    pathOriginCAHelpSyn,
+		HelpDescription: pathOriginCAHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathOriginCAWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("name").(string)
+
+	roleEntry, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %w", err)
+	}
+
+	if roleEntry == nil {
+		return nil, errors.New("error retrieving role: role is nil")
+	}
+
+	if roleEntry.CredentialType != credentialTypeOriginCA {
+		return logical.ErrorResponse("role %q does not issue origin ca certificates", roleName), nil
+	}
+
+	hostnames := d.Get("hostnames").([]string)
+	keyType := roleEntry.KeyType
+
+	csr := d.Get("csr").(string)
+	if csr != "" {
+		parsed, err := parseCSR(csr)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if csrKeyType(parsed) != keyType {
+			return logical.ErrorResponse("csr key type does not match role key type %q", keyType), nil
+		}
+		if len(hostnames) == 0 {
+			hostnames = csrHostnames(parsed)
+		}
+	} else if len(hostnames) == 0 {
+		hostnames = roleEntry.AllowedHostnames
+	}
+
+	if len(hostnames) == 0 {
+		return logical.ErrorResponse("no hostnames requested"), nil
+	}
+
+	for _, hostname := range hostnames {
+		if !hostnameAllowed(roleEntry.AllowedHostnames, hostname) {
+			return logical.ErrorResponse("hostname %q is not allowed by role %q", hostname, roleName), nil
+		}
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, 1); resp != nil || err != nil {
+		return resp, err
+	}
+
+	var keyPair *certificateKeyPair
+	if csr == "" {
+		keyPair, err = generateKeyAndCSR(keyType, hostnames[0], hostnames)
+		if err != nil {
+			return nil, err
+		}
+		csr = keyPair.CSR
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := createOriginCACertificate(ctx, client, hostnames, keyType, csr, roleEntry.ValidityDays)
+	if err != nil {
+		return cloudflareErrorResponse(req, err)
+	}
+
+	if keyPair != nil {
+		cert.PrivateKey = keyPair.PrivateKey
+		cert.PrivateKeyType = keyPair.PrivateKeyType
+	}
+
+	root, hasRoot := originCARoots[keyType]
+	if hasRoot {
+		cert.CAChain = []string{root}
+	}
+
+	resp := b.Secret(cloudflareOriginCACertificateType).Response(cert.toResponseData(), map[string]interface{}{
+		"certificate_id": cert.CertificateID,
+		"expiration":     cert.Expiration.Format(time.RFC3339),
+		"role":           roleName,
+	})
+
+	if lifetime := time.Until(cert.Expiration); lifetime > 0 {
+		resp.Secret.MaxTTL = lifetime
+	}
+
+	if !hasRoot {
+		resp.AddWarning(fmt.Sprintf("ca_chain is not available for key type %q, use the Origin CA root Cloudflare publishes for it", keyType))
+	}
+
+	return resp, nil
+}
+
+const pathOriginCAHelpSyn = `
+Issue a Cloudflare Origin CA certificate from a specific Vault role.
+`
+
+const pathOriginCAHelpDesc = `
+This path issues a Cloudflare Origin CA certificate based on a particular role.
+A certificate signing request may be supplied, otherwise Vault generates the
+key pair and returns the private key alongside the certificate. The certificate
+is revoked when the lease ends.
+
+Origin CA certificates are issued directly by the Cloudflare Origin CA root,
+which is returned as ca_chain for clients that verify origins themselves.
+ca_chain is currently only returned for RSA keys; for ECDSA keys, use the
+Origin CA ECC root that Cloudflare publishes.
+
+Hostnames in the role may contain globs, which match within a single label:
+"*.example.com" allows "www.example.com" and "*.example.com", but not
+"a.b.example.com".
+`
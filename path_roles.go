@@ -3,13 +3,73 @@ package cloudflare_secrets_engine
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const (
+	credentialTypeService    = "service"
+	credentialTypeAPI        = "api"
+	credentialTypeOriginCA   = "origin-ca"
+	credentialTypeClientCert = "client-cert"
+	credentialTypeDNSRecord  = "dns-record"
+	credentialTypeJITAccess  = "jit-access"
+	credentialTypeMember     = "member"
+	credentialTypeFirewall   = "firewall-allow"
+)
+
 type cloudflareRoleEntry struct {
-	CredentialType string `json:"type"`
-	AccountID      string `json:"account_id"`
+	CredentialType   string   `json:"type"`
+	AccountID        string   `json:"account_id"`
+	ZoneID           string   `json:"zone_id,omitempty"`
+	AllowedHostnames []string `json:"allowed_hostnames,omitempty"`
+	KeyType          string   `json:"key_type,omitempty"`
+	ValidityDays     int      `json:"validity_days,omitempty"`
+
+	AllowedRecordTypes []string `json:"allowed_record_types,omitempty"`
+	AllowedRecordNames []string `json:"allowed_record_names,omitempty"`
+	AllowedContent     []string `json:"allowed_content,omitempty"`
+
+	ApplicationID       string            `json:"application_id,omitempty"`
+	PolicyID            string            `json:"policy_id,omitempty"`
+	EmailMetadataKey    string            `json:"email_metadata_key,omitempty"`
+	BoundEntityMetadata map[string]string `json:"bound_entity_metadata,omitempty"`
+
+	AccountRoles  []string `json:"account_roles,omitempty"`
+	AllowedEmails []string `json:"allowed_emails,omitempty"`
+	MemberStatus  string   `json:"member_status,omitempty"`
+
+	ListID              string   `json:"list_id,omitempty"`
+	AllowedCIDRs        []string `json:"allowed_cidrs,omitempty"`
+	MinPrefixLengthIPv4 int      `json:"min_prefix_length_ipv4,omitempty"`
+	MinPrefixLengthIPv6 int      `json:"min_prefix_length_ipv6,omitempty"`
+
+	WorkerSecrets []string `json:"worker_secrets,omitempty"`
+	NameTemplate  string   `json:"name_template,omitempty"`
+
+	MaxTTL         time.Duration `json:"max_ttl,omitempty"`
+	AllowNarrowing bool          `json:"allow_narrowing,omitempty"`
+	MaxBatch       int           `json:"max_batch,omitempty"`
+	MaxActive      int           `json:"max_active,omitempty"`
+
+	MaxIdle         time.Duration `json:"max_idle,omitempty"`
+	IdleGracePeriod time.Duration `json:"idle_grace_period,omitempty"`
+
+	RateLimit         int           `json:"rate_limit,omitempty"`
+	RateLimitInterval time.Duration `json:"rate_limit_interval,omitempty"`
+	RateLimitBurst    int           `json:"burst,omitempty"`
+
+	Policies   string `json:"policies,omitempty"`
+	TokenOwner string `json:"token_owner,omitempty"`
+
+	TokenAllowedCIDRs []string `json:"token_allowed_cidrs,omitempty"`
+	TokenDeniedCIDRs  []string `json:"token_denied_cidrs,omitempty"`
+	BindToRequesterIP bool     `json:"bind_to_requester_ip,omitempty"`
 }
 
 func pathRole(b *cloudflareBackend) []*framework.Path {
@@ -24,13 +84,156 @@ func pathRole(b *cloudflareBackend) []*framework.Path {
 				},
 				"credential_type": {
 					Type:        framework.TypeString,
-					Description: fmt.Sprintf("The credential type, either \"service\" for Access or \"api\" for API"),
+					Description: fmt.Sprintf("The credential type, either \"service\" for Access, \"api\" for API, \"origin-ca\" for Origin CA certificates, \"client-cert\" for API Shield client certificates, \"dns-record\" for DNS records, \"jit-access\" for Access policy grants, \"member\" for account memberships or \"firewall-allow\" for IP allowlist entries"),
 					Required:    true,
 				},
 				"account_id": {
 					Type:        framework.TypeString,
 					Description: fmt.Sprintf("The cloudflare account id to generate credentials for"),
-					Required:    true,
+				},
+				"zone_id": {
+					Type:        framework.TypeString,
+					Description: "The cloudflare zone id to generate credentials for",
+				},
+				"allowed_hostnames": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Hostnames, which may contain globs matching within a single label, that Origin CA certificates can be issued for",
+				},
+				"key_type": {
+					Type:        framework.TypeString,
+					Description: "The key type of generated certificates, either \"rsa\" or \"ecdsa\"",
+					Default:     keyTypeRSA,
+				},
+				"validity_days": {
+					Type:        framework.TypeInt,
+					Description: "The number of days generated certificates are valid for",
+					Default:     originCADefaultValidityDays,
+				},
+				"allowed_record_types": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "DNS record types that can be created, \"TXT\" and/or \"CNAME\"",
+				},
+				"allowed_record_names": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "DNS record names, which may contain globs that each match a single label, that can be created",
+				},
+				"allowed_content": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "CNAME record targets, which may contain globs matching within a single label, that can be created. Required when CNAME records are allowed",
+				},
+				"application_id": {
+					Type:        framework.TypeString,
+					Description: "The Access application id to grant access to",
+				},
+				"policy_id": {
+					Type:        framework.TypeString,
+					Description: "The dedicated Access policy id that email include rules are added to. It must keep at least one other include rule, as Cloudflare rejects policies without any",
+				},
+				"email_metadata_key": {
+					Type:        framework.TypeString,
+					Description: "The identity entity metadata key holding the requester's email address",
+					Default:     defaultEmailMetadataKey,
+				},
+				"bound_entity_metadata": {
+					Type:        framework.TypeKVPairs,
+					Description: "Identity entity metadata the requester must have to be granted access",
+				},
+				"account_roles": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The Cloudflare account role ids granted to members",
+				},
+				"allowed_emails": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Email addresses, which may contain globs, that can be added as members",
+				},
+				"member_status": {
+					Type:        framework.TypeString,
+					Description: "The status of added members, \"pending\" to send an invitation or \"accepted\" to add them directly",
+					Default:     memberStatusPending,
+				},
+				"list_id": {
+					Type:        framework.TypeString,
+					Description: "The account IP list id that entries are added to. If omitted, IP Access Rules are created on the role's zone or account instead",
+				},
+				"allowed_cidrs": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "CIDR blocks that explicitly requested addresses must fall within. If empty, only the requester's address can be added",
+				},
+				"min_prefix_length_ipv4": {
+					Type:        framework.TypeInt,
+					Description: "The shortest IPv4 prefix length, and so the largest network, that can be added",
+					Default:     32,
+				},
+				"min_prefix_length_ipv6": {
+					Type:        framework.TypeInt,
+					Description: "The shortest IPv6 prefix length, and so the largest network, that can be added. Requester IPv6 addresses are added as their network of this length",
+					Default:     64,
+				},
+				"policies": {
+					Type:        framework.TypeString,
+					Description: "JSON encoded list of Cloudflare API token policies granted to generated API tokens",
+				},
+				"token_owner": {
+					Type:        framework.TypeString,
+					Description: "The owner of generated API tokens, \"user\" for the user of the configured credentials or \"account\" for the role's account",
+					Default:     tokenOwnerUser,
+				},
+				"token_allowed_cidrs": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "CIDR blocks generated API tokens can be used from",
+				},
+				"token_denied_cidrs": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "CIDR blocks generated API tokens cannot be used from",
+				},
+				"bind_to_requester_ip": {
+					Type:        framework.TypeBool,
+					Description: "Restrict each generated API token to the address of the Vault client that requested it. IPv6 clients are bound to their /64 network",
+				},
+				"name_template": {
+					Type:        framework.TypeString,
+					Description: "Template for the names of generated service and API tokens. It may use .RoleName, .DisplayName, .EntityName, .MountAccessor and Vault's template functions such as uuid, random and timestamp. Names are truncated to 120 characters",
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The maximum lease TTL of generated service and API tokens, including TTLs requested by callers. Defaults to the mount's max lease TTL",
+				},
+				"allow_narrowing": {
+					Type:        framework.TypeBool,
+					Description: "Allow callers to restrict generated API tokens to a subset of the role's zones and permission groups",
+				},
+				"max_batch": {
+					Type:        framework.TypeInt,
+					Description: "The maximum number of service tokens a single request can generate. Defaults to 1, disabling batches",
+				},
+				"max_active": {
+					Type:        framework.TypeInt,
+					Description: "The maximum number of unexpired service or API tokens the role can have at once, including tokens issued while it was unset. Defaults to 0, no limit",
+				},
+				"max_idle": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Delete generated service tokens at Cloudflare once Access has not seen them used for this long. Defaults to 0, never",
+				},
+				"idle_grace_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long after issuance a service token that has never been used is deleted, when max_idle is set. Defaults to max_idle",
+				},
+				"rate_limit": {
+					Type:        framework.TypeInt,
+					Description: "The number of credentials the role can issue per rate_limit_interval, counting every token of a batch. Defaults to 0, no limit",
+				},
+				"rate_limit_interval": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The interval rate_limit applies to",
+					Default:     int(defaultRateLimitInterval.Seconds()),
+				},
+				"burst": {
+					Type:        framework.TypeInt,
+					Description: "The number of credentials the role can issue at once before rate_limit applies. Defaults to rate_limit",
+				},
+				"worker_secrets": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Worker secret bindings, as script:BINDING or script:BINDING:field, that issued service tokens are written to. Without a field the whole credential is written as JSON",
 				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -85,8 +288,64 @@ func (b *cloudflareBackend) pathRolesRead(ctx context.Context, req *logical.Requ
 
 	var data = make(map[string]interface{})
 	data["credential_type"] = entry.CredentialType
-	if entry.CredentialType == "service" {
+	if entry.RateLimit > 0 {
+		data["rate_limit"] = entry.RateLimit
+		data["rate_limit_interval"] = int64(entry.RateLimitInterval.Seconds())
+		data["burst"] = entry.RateLimitBurst
+	}
+	switch entry.CredentialType {
+	case credentialTypeService:
+		data["account_id"] = entry.AccountID
+		data["worker_secrets"] = entry.WorkerSecrets
+		data["name_template"] = entry.NameTemplate
+		data["max_ttl"] = int64(entry.MaxTTL.Seconds())
+		data["max_batch"] = entry.MaxBatch
+		data["max_active"] = entry.MaxActive
+		data["max_idle"] = int64(entry.MaxIdle.Seconds())
+		data["idle_grace_period"] = int64(entry.IdleGracePeriod.Seconds())
+	case credentialTypeAPI:
+		data["account_id"] = entry.AccountID
+		data["policies"] = entry.Policies
+		data["token_owner"] = entry.TokenOwner
+		data["token_allowed_cidrs"] = entry.TokenAllowedCIDRs
+		data["token_denied_cidrs"] = entry.TokenDeniedCIDRs
+		data["bind_to_requester_ip"] = entry.BindToRequesterIP
+		data["name_template"] = entry.NameTemplate
+		data["max_ttl"] = int64(entry.MaxTTL.Seconds())
+		data["allow_narrowing"] = entry.AllowNarrowing
+		data["max_active"] = entry.MaxActive
+	case credentialTypeOriginCA:
+		data["allowed_hostnames"] = entry.AllowedHostnames
+		data["key_type"] = entry.KeyType
+		data["validity_days"] = entry.ValidityDays
+	case credentialTypeClientCert:
+		data["zone_id"] = entry.ZoneID
+		data["key_type"] = entry.KeyType
+		data["validity_days"] = entry.ValidityDays
+	case credentialTypeDNSRecord:
+		data["zone_id"] = entry.ZoneID
+		data["allowed_record_types"] = entry.AllowedRecordTypes
+		data["allowed_record_names"] = entry.AllowedRecordNames
+		data["allowed_content"] = entry.AllowedContent
+	case credentialTypeJITAccess:
+		data["account_id"] = entry.AccountID
+		data["application_id"] = entry.ApplicationID
+		data["policy_id"] = entry.PolicyID
+		data["email_metadata_key"] = entry.EmailMetadataKey
+		data["bound_entity_metadata"] = entry.BoundEntityMetadata
+	case credentialTypeMember:
+		data["account_id"] = entry.AccountID
+		data["account_roles"] = entry.AccountRoles
+		data["allowed_emails"] = entry.AllowedEmails
+		data["member_status"] = entry.MemberStatus
+		data["email_metadata_key"] = entry.EmailMetadataKey
+	case credentialTypeFirewall:
 		data["account_id"] = entry.AccountID
+		data["zone_id"] = entry.ZoneID
+		data["list_id"] = entry.ListID
+		data["allowed_cidrs"] = entry.AllowedCIDRs
+		data["min_prefix_length_ipv4"] = entry.MinPrefixLengthIPv4
+		data["min_prefix_length_ipv6"] = entry.MinPrefixLengthIPv6
 	}
 
 	return &logical.Response{
@@ -112,9 +371,10 @@ func (b *cloudflareBackend) pathRolesWrite(ctx context.Context, req *logical.Req
 	createOperation := req.Operation == logical.CreateOperation
 
 	if credentialType, ok := d.GetOk("credential_type"); ok {
-		if credentialType == "service" {
+		switch credentialType {
+		case credentialTypeService, credentialTypeAPI, credentialTypeOriginCA, credentialTypeClientCert, credentialTypeDNSRecord, credentialTypeJITAccess, credentialTypeMember, credentialTypeFirewall:
 			roleEntry.CredentialType = credentialType.(string)
-		} else {
+		default:
 			return nil, fmt.Errorf("invalid credential_type in cloudflare role")
 		}
 	} else if !ok && createOperation {
@@ -123,23 +383,360 @@ func (b *cloudflareBackend) pathRolesWrite(ctx context.Context, req *logical.Req
 
 	if accountId, ok := d.GetOk("account_id"); ok {
 		roleEntry.AccountID = accountId.(string)
-	} else if !ok && createOperation {
-		return nil, fmt.Errorf("missing account_id in cloudflare role")
+	}
+
+	if zoneId, ok := d.GetOk("zone_id"); ok {
+		roleEntry.ZoneID = zoneId.(string)
+	}
+
+	if allowedHostnames, ok := d.GetOk("allowed_hostnames"); ok {
+		roleEntry.AllowedHostnames = allowedHostnames.([]string)
+	}
+
+	if allowedRecordTypes, ok := d.GetOk("allowed_record_types"); ok {
+		roleEntry.AllowedRecordTypes = allowedRecordTypes.([]string)
+	}
+
+	if allowedRecordNames, ok := d.GetOk("allowed_record_names"); ok {
+		roleEntry.AllowedRecordNames = allowedRecordNames.([]string)
+	}
+
+	if allowedContent, ok := d.GetOk("allowed_content"); ok {
+		roleEntry.AllowedContent = allowedContent.([]string)
+	}
+
+	if applicationId, ok := d.GetOk("application_id"); ok {
+		roleEntry.ApplicationID = applicationId.(string)
+	}
+
+	if policyId, ok := d.GetOk("policy_id"); ok {
+		roleEntry.PolicyID = policyId.(string)
+	}
+
+	if emailMetadataKey, ok := d.GetOk("email_metadata_key"); ok {
+		roleEntry.EmailMetadataKey = emailMetadataKey.(string)
+	}
+
+	if boundEntityMetadata, ok := d.GetOk("bound_entity_metadata"); ok {
+		roleEntry.BoundEntityMetadata = boundEntityMetadata.(map[string]string)
+	}
+
+	if accountRoles, ok := d.GetOk("account_roles"); ok {
+		roleEntry.AccountRoles = accountRoles.([]string)
+	}
+
+	if allowedEmails, ok := d.GetOk("allowed_emails"); ok {
+		roleEntry.AllowedEmails = allowedEmails.([]string)
+	}
+
+	if memberStatus, ok := d.GetOk("member_status"); ok {
+		roleEntry.MemberStatus = memberStatus.(string)
+	}
+
+	if listId, ok := d.GetOk("list_id"); ok {
+		roleEntry.ListID = listId.(string)
+	}
+
+	if allowedCIDRs, ok := d.GetOk("allowed_cidrs"); ok {
+		roleEntry.AllowedCIDRs = allowedCIDRs.([]string)
+	}
+
+	if minPrefixLength, ok := d.GetOk("min_prefix_length_ipv4"); ok {
+		roleEntry.MinPrefixLengthIPv4 = minPrefixLength.(int)
+	}
+
+	if minPrefixLength, ok := d.GetOk("min_prefix_length_ipv6"); ok {
+		roleEntry.MinPrefixLengthIPv6 = minPrefixLength.(int)
+	}
+
+	if policies, ok := d.GetOk("policies"); ok {
+		roleEntry.Policies = policies.(string)
+	}
+
+	if tokenOwner, ok := d.GetOk("token_owner"); ok {
+		roleEntry.TokenOwner = tokenOwner.(string)
+	}
+
+	if tokenAllowedCIDRs, ok := d.GetOk("token_allowed_cidrs"); ok {
+		roleEntry.TokenAllowedCIDRs = tokenAllowedCIDRs.([]string)
+	}
+
+	if tokenDeniedCIDRs, ok := d.GetOk("token_denied_cidrs"); ok {
+		roleEntry.TokenDeniedCIDRs = tokenDeniedCIDRs.([]string)
+	}
+
+	if bindToRequesterIP, ok := d.GetOk("bind_to_requester_ip"); ok {
+		roleEntry.BindToRequesterIP = bindToRequesterIP.(bool)
+	}
+
+	if nameTemplate, ok := d.GetOk("name_template"); ok {
+		roleEntry.NameTemplate = nameTemplate.(string)
+	}
+
+	if maxTTL, ok := d.GetOk("max_ttl"); ok {
+		roleEntry.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	}
+
+	if allowNarrowing, ok := d.GetOk("allow_narrowing"); ok {
+		roleEntry.AllowNarrowing = allowNarrowing.(bool)
+	}
+
+	if maxBatch, ok := d.GetOk("max_batch"); ok {
+		roleEntry.MaxBatch = maxBatch.(int)
+	}
+
+	if maxActive, ok := d.GetOk("max_active"); ok {
+		roleEntry.MaxActive = maxActive.(int)
+	}
+
+	if maxIdle, ok := d.GetOk("max_idle"); ok {
+		roleEntry.MaxIdle = time.Duration(maxIdle.(int)) * time.Second
+	}
+
+	if idleGracePeriod, ok := d.GetOk("idle_grace_period"); ok {
+		roleEntry.IdleGracePeriod = time.Duration(idleGracePeriod.(int)) * time.Second
+	}
+
+	if rateLimit, ok := d.GetOk("rate_limit"); ok {
+		roleEntry.RateLimit = rateLimit.(int)
+	}
+
+	if rateLimitInterval, ok := d.GetOk("rate_limit_interval"); ok {
+		roleEntry.RateLimitInterval = time.Duration(rateLimitInterval.(int)) * time.Second
+	} else if roleEntry.RateLimitInterval == 0 {
+		roleEntry.RateLimitInterval = time.Duration(d.Get("rate_limit_interval").(int)) * time.Second
+	}
+
+	if burst, ok := d.GetOk("burst"); ok {
+		roleEntry.RateLimitBurst = burst.(int)
+	}
+
+	if workerSecrets, ok := d.GetOk("worker_secrets"); ok {
+		roleEntry.WorkerSecrets = workerSecrets.([]string)
+	}
+
+	if keyType, ok := d.GetOk("key_type"); ok {
+		roleEntry.KeyType = keyType.(string)
+	}
+
+	if validityDays, ok := d.GetOk("validity_days"); ok {
+		roleEntry.ValidityDays = validityDays.(int)
+	}
+
+	if len(roleEntry.WorkerSecrets) > 0 && roleEntry.CredentialType != credentialTypeService {
+		return nil, fmt.Errorf("worker_secrets is only supported for service roles")
+	}
+
+	if roleEntry.MaxTTL < 0 {
+		return nil, fmt.Errorf("max_ttl must not be negative")
+	}
+
+	if roleEntry.RateLimit < 0 || roleEntry.RateLimitBurst < 0 || roleEntry.RateLimitInterval <= 0 {
+		return nil, fmt.Errorf("rate_limit, rate_limit_interval and burst must be positive")
+	}
+
+	if roleEntry.MaxActive < 0 {
+		return nil, fmt.Errorf("max_active must not be negative")
+	}
+
+	if roleEntry.MaxActive > 0 && roleEntry.CredentialType != credentialTypeService && roleEntry.CredentialType != credentialTypeAPI {
+		return nil, fmt.Errorf("max_active is only supported for service and api roles")
+	}
+
+	if roleEntry.MaxIdle < 0 || roleEntry.IdleGracePeriod < 0 {
+		return nil, fmt.Errorf("max_idle and idle_grace_period must not be negative")
+	}
+
+	if (roleEntry.MaxIdle > 0 || roleEntry.IdleGracePeriod > 0) && roleEntry.CredentialType != credentialTypeService {
+		return nil, fmt.Errorf("max_idle and idle_grace_period are only supported for service roles")
+	}
+
+	if roleEntry.NameTemplate != "" {
+		if roleEntry.CredentialType != credentialTypeService && roleEntry.CredentialType != credentialTypeAPI {
+			return nil, fmt.Errorf("name_template is only supported for service and api roles")
+		}
+		if err := validateNameTemplate(roleEntry.NameTemplate); err != nil {
+			return nil, fmt.Errorf("invalid name_template in cloudflare role: %w", err)
+		}
+	}
+
+	switch roleEntry.CredentialType {
+	case credentialTypeService:
+		if roleEntry.AccountID == "" {
+			return nil, fmt.Errorf("missing account_id in cloudflare role")
+		}
+		for _, target := range roleEntry.WorkerSecrets {
+			if _, err := parseWorkerSecretTarget(target); err != nil {
+				return nil, fmt.Errorf("invalid worker_secrets in cloudflare role: %w", err)
+			}
+		}
+		if roleEntry.MaxBatch < 0 || roleEntry.MaxBatch > maxServiceTokenBatch {
+			return nil, fmt.Errorf("invalid max_batch in cloudflare role: %d", roleEntry.MaxBatch)
+		}
+	case credentialTypeAPI:
+		if roleEntry.TokenOwner == "" {
+			roleEntry.TokenOwner = d.Get("token_owner").(string)
+		}
+		if roleEntry.Policies == "" {
+			return nil, fmt.Errorf("missing policies in cloudflare role")
+		}
+		if _, err := apiTokenPolicies(roleEntry.Policies); err != nil {
+			return nil, fmt.Errorf("invalid policies in cloudflare role: %w", err)
+		}
+		if roleEntry.TokenOwner != tokenOwnerUser && roleEntry.TokenOwner != tokenOwnerAccount {
+			return nil, fmt.Errorf("invalid token_owner in cloudflare role: %q", roleEntry.TokenOwner)
+		}
+		if roleEntry.TokenOwner == tokenOwnerAccount && roleEntry.AccountID == "" {
+			return nil, fmt.Errorf("missing account_id in cloudflare role")
+		}
+		for _, cidr := range roleEntry.TokenAllowedCIDRs {
+			if _, err := netip.ParsePrefix(cidr); err != nil {
+				return nil, fmt.Errorf("invalid token_allowed_cidrs in cloudflare role: %w", err)
+			}
+		}
+		for _, cidr := range roleEntry.TokenDeniedCIDRs {
+			if _, err := netip.ParsePrefix(cidr); err != nil {
+				return nil, fmt.Errorf("invalid token_denied_cidrs in cloudflare role: %w", err)
+			}
+		}
+	case credentialTypeOriginCA:
+		if roleEntry.KeyType == "" {
+			roleEntry.KeyType = d.Get("key_type").(string)
+		}
+		if roleEntry.ValidityDays == 0 {
+			roleEntry.ValidityDays = d.Get("validity_days").(int)
+		}
+		if len(roleEntry.AllowedHostnames) == 0 {
+			return nil, fmt.Errorf("missing allowed_hostnames in cloudflare role")
+		}
+		if _, err := originCARequestType(roleEntry.KeyType); err != nil {
+			return nil, err
+		}
+		if !validOriginCAValidity(roleEntry.ValidityDays) {
+			return nil, fmt.Errorf("invalid validity_days in cloudflare role: %d", roleEntry.ValidityDays)
+		}
+	case credentialTypeClientCert:
+		if roleEntry.KeyType == "" {
+			roleEntry.KeyType = d.Get("key_type").(string)
+		}
+		if roleEntry.ValidityDays == 0 {
+			roleEntry.ValidityDays = d.Get("validity_days").(int)
+		}
+		if roleEntry.ZoneID == "" {
+			return nil, fmt.Errorf("missing zone_id in cloudflare role")
+		}
+		if !validKeyType(roleEntry.KeyType) {
+			return nil, fmt.Errorf("invalid key_type in cloudflare role: %q", roleEntry.KeyType)
+		}
+		if roleEntry.ValidityDays < 1 || roleEntry.ValidityDays > clientCertMaxValidityDays {
+			return nil, fmt.Errorf("invalid validity_days in cloudflare role: %d", roleEntry.ValidityDays)
+		}
+	case credentialTypeDNSRecord:
+		if roleEntry.ZoneID == "" {
+			return nil, fmt.Errorf("missing zone_id in cloudflare role")
+		}
+		if len(roleEntry.AllowedRecordTypes) == 0 {
+			return nil, fmt.Errorf("missing allowed_record_types in cloudflare role")
+		}
+		for i, recordType := range roleEntry.AllowedRecordTypes {
+			recordType = strings.ToUpper(recordType)
+			if !strutil.StrListContains(dnsRecordTypes, recordType) {
+				return nil, fmt.Errorf("invalid allowed_record_types in cloudflare role: %q", recordType)
+			}
+			roleEntry.AllowedRecordTypes[i] = recordType
+		}
+		if len(roleEntry.AllowedRecordNames) == 0 {
+			return nil, fmt.Errorf("missing allowed_record_names in cloudflare role")
+		}
+		for i, recordName := range roleEntry.AllowedRecordNames {
+			roleEntry.AllowedRecordNames[i] = strings.ToLower(strings.TrimSuffix(recordName, "."))
+		}
+		if strutil.StrListContains(roleEntry.AllowedRecordTypes, "CNAME") && len(roleEntry.AllowedContent) == 0 {
+			return nil, fmt.Errorf("missing allowed_content in cloudflare role, required for CNAME records")
+		}
+		for i, content := range roleEntry.AllowedContent {
+			roleEntry.AllowedContent[i] = strings.ToLower(strings.TrimSuffix(content, "."))
+		}
+	case credentialTypeJITAccess:
+		if roleEntry.EmailMetadataKey == "" {
+			roleEntry.EmailMetadataKey = d.Get("email_metadata_key").(string)
+		}
+		if roleEntry.AccountID == "" {
+			return nil, fmt.Errorf("missing account_id in cloudflare role")
+		}
+		if roleEntry.ApplicationID == "" {
+			return nil, fmt.Errorf("missing application_id in cloudflare role")
+		}
+		if roleEntry.PolicyID == "" {
+			return nil, fmt.Errorf("missing policy_id in cloudflare role")
+		}
+	case credentialTypeMember:
+		if roleEntry.EmailMetadataKey == "" {
+			roleEntry.EmailMetadataKey = d.Get("email_metadata_key").(string)
+		}
+		if roleEntry.MemberStatus == "" {
+			roleEntry.MemberStatus = d.Get("member_status").(string)
+		}
+		if roleEntry.AccountID == "" {
+			return nil, fmt.Errorf("missing account_id in cloudflare role")
+		}
+		if len(roleEntry.AccountRoles) == 0 {
+			return nil, fmt.Errorf("missing account_roles in cloudflare role")
+		}
+		if roleEntry.MemberStatus != memberStatusPending && roleEntry.MemberStatus != memberStatusAccepted {
+			return nil, fmt.Errorf("invalid member_status in cloudflare role: %q", roleEntry.MemberStatus)
+		}
+		for i, email := range roleEntry.AllowedEmails {
+			roleEntry.AllowedEmails[i] = strings.ToLower(email)
+		}
+	case credentialTypeFirewall:
+		if roleEntry.MinPrefixLengthIPv4 == 0 {
+			roleEntry.MinPrefixLengthIPv4 = d.Get("min_prefix_length_ipv4").(int)
+		}
+		if roleEntry.MinPrefixLengthIPv6 == 0 {
+			roleEntry.MinPrefixLengthIPv6 = d.Get("min_prefix_length_ipv6").(int)
+		}
+		if roleEntry.ListID != "" && roleEntry.AccountID == "" {
+			return nil, fmt.Errorf("missing account_id in cloudflare role")
+		}
+		if roleEntry.AccountID == "" && roleEntry.ZoneID == "" {
+			return nil, fmt.Errorf("missing account_id or zone_id in cloudflare role")
+		}
+		if roleEntry.AccountID != "" && roleEntry.ZoneID != "" {
+			return nil, fmt.Errorf("only one of account_id or zone_id can be set in cloudflare role")
+		}
+		if roleEntry.MinPrefixLengthIPv4 < 1 || roleEntry.MinPrefixLengthIPv4 > 32 {
+			return nil, fmt.Errorf("invalid min_prefix_length_ipv4 in cloudflare role: %d", roleEntry.MinPrefixLengthIPv4)
+		}
+		if roleEntry.MinPrefixLengthIPv6 < 1 || roleEntry.MinPrefixLengthIPv6 > 128 {
+			return nil, fmt.Errorf("invalid min_prefix_length_ipv6 in cloudflare role: %d", roleEntry.MinPrefixLengthIPv6)
+		}
+		for _, cidr := range roleEntry.AllowedCIDRs {
+			if _, err := netip.ParsePrefix(cidr); err != nil {
+				return nil, fmt.Errorf("invalid allowed_cidrs in cloudflare role: %w", err)
+			}
+		}
 	}
 
 	if err := setRole(ctx, req.Storage, name.(string), roleEntry); err != nil {
 		return nil, err
 	}
 
+	b.forgetIssuanceLimiter(name.(string))
+
 	return nil, nil
 }
 
 func (b *cloudflareBackend) pathRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	err := req.Storage.Delete(ctx, "role/"+d.Get("name").(string))
+	name := d.Get("name").(string)
+
+	err := req.Storage.Delete(ctx, "role/"+name)
 	if err != nil {
 		return nil, fmt.Errorf("error deleting cloudflare role: %w", err)
 	}
 
+	b.forgetIssuanceLimiter(name)
+
 	return nil, nil
 }
 
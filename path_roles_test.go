@@ -140,3 +140,444 @@ func testServiceRoleDelete(t *testing.T, b *cloudflareBackend, s logical.Storage
 		Storage:   s,
 	})
 }
+
+func TestOriginCARole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create Origin CA Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "origin", map[string]interface{}{
+			"credential_type":   "origin-ca",
+			"allowed_hostnames": "example.com,*.example.com",
+			"key_type":          "ecdsa",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Origin CA Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "origin")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, []string{"example.com", "*.example.com"}, resp.Data["allowed_hostnames"])
+		require.Equal(t, "ecdsa", resp.Data["key_type"])
+		require.Equal(t, 90, resp.Data["validity_days"])
+	})
+
+	t.Run("Reject Invalid Validity", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "origin-invalid", map[string]interface{}{
+			"credential_type":   "origin-ca",
+			"allowed_hostnames": "example.com",
+			"validity_days":     10,
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Missing Hostnames", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "origin-invalid", map[string]interface{}{
+			"credential_type": "origin-ca",
+		})
+
+		require.Error(t, err)
+	})
+}
+
+func TestClientCertRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create Client Cert Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "devices", map[string]interface{}{
+			"credential_type": "client-cert",
+			"zone_id":         "testzoneid",
+			"validity_days":   30,
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Client Cert Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "devices")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, "testzoneid", resp.Data["zone_id"])
+		require.Equal(t, "rsa", resp.Data["key_type"])
+		require.Equal(t, 30, resp.Data["validity_days"])
+	})
+
+	t.Run("Reject Missing Zone", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "devices-invalid", map[string]interface{}{
+			"credential_type": "client-cert",
+		})
+
+		require.Error(t, err)
+	})
+}
+
+func TestDNSRecordRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create DNS Record Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "acme", map[string]interface{}{
+			"credential_type":      "dns-record",
+			"zone_id":              "testzoneid",
+			"allowed_record_types": "txt",
+			"allowed_record_names": "_acme-challenge.*.Example.com.",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read DNS Record Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "acme")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, []string{"TXT"}, resp.Data["allowed_record_types"])
+		require.Equal(t, []string{"_acme-challenge.*.example.com"}, resp.Data["allowed_record_names"])
+	})
+
+	t.Run("Reject Unsupported Record Type", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "acme-invalid", map[string]interface{}{
+			"credential_type":      "dns-record",
+			"zone_id":              "testzoneid",
+			"allowed_record_types": "A",
+			"allowed_record_names": "*.example.com",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Require Allowed Content For CNAME Records", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "acme-cname", map[string]interface{}{
+			"credential_type":      "dns-record",
+			"zone_id":              "testzoneid",
+			"allowed_record_types": "CNAME",
+			"allowed_record_names": "_acme-challenge.*.example.com",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Disallowed Record Name", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "dns-record/acme",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"record_name": "www.example.com",
+				"content":     "challenge",
+			},
+		})
+
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func TestJITAccessRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create JIT Access Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "break-glass", map[string]interface{}{
+			"credential_type":       "jit-access",
+			"account_id":            accountId,
+			"application_id":        "testapplicationid",
+			"policy_id":             "testpolicyid",
+			"bound_entity_metadata": "team=sre",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read JIT Access Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "break-glass")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, "testpolicyid", resp.Data["policy_id"])
+		require.Equal(t, "email", resp.Data["email_metadata_key"])
+		require.Equal(t, map[string]string{"team": "sre"}, resp.Data["bound_entity_metadata"])
+	})
+
+	t.Run("Reject Missing Policy", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "break-glass-invalid", map[string]interface{}{
+			"credential_type": "jit-access",
+			"account_id":      accountId,
+			"application_id":  "testapplicationid",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Request Without Entity", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "jit-access/break-glass",
+			Storage:   s,
+		})
+
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func TestMemberRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create Member Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "contractors", map[string]interface{}{
+			"credential_type": "member",
+			"account_id":      accountId,
+			"account_roles":   "roleid1,roleid2",
+			"allowed_emails":  "*@Contractor.example.com",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Member Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "contractors")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, []string{"roleid1", "roleid2"}, resp.Data["account_roles"])
+		require.Equal(t, []string{"*@contractor.example.com"}, resp.Data["allowed_emails"])
+		require.Equal(t, "pending", resp.Data["member_status"])
+	})
+
+	t.Run("Reject Invalid Status", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "contractors-invalid", map[string]interface{}{
+			"credential_type": "member",
+			"account_id":      accountId,
+			"account_roles":   "roleid1",
+			"member_status":   "active",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Disallowed Email", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "member/contractors",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"email": "someone@example.com",
+			},
+		})
+
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func TestFirewallAllowRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create Firewall Allow Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "staging", map[string]interface{}{
+			"credential_type": "firewall-allow",
+			"zone_id":         "testzoneid",
+			"allowed_cidrs":   "10.0.0.0/8",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Firewall Allow Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "staging")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.NotContains(t, resp.Data, "access_rule_mode")
+		require.Equal(t, 32, resp.Data["min_prefix_length_ipv4"])
+		require.Equal(t, 64, resp.Data["min_prefix_length_ipv6"])
+	})
+
+	t.Run("Reject Account And Zone", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "staging-invalid", map[string]interface{}{
+			"credential_type": "firewall-allow",
+			"account_id":      accountId,
+			"zone_id":         "testzoneid",
+		})
+
+		require.Error(t, err)
+	})
+
+	for name, ip := range map[string]string{
+		"Reject IP Outside Allowed CIDRs": "192.168.1.1",
+		"Reject Network Too Broad":        "10.1.0.0/16",
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := b.HandleRequest(context.Background(), &logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "firewall-allow/staging",
+				Storage:   s,
+				Data: map[string]interface{}{
+					"ip": ip,
+				},
+			})
+
+			require.NoError(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+}
+
+func TestServiceRoleWorkerSecrets(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create Service Role With Worker Secrets", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "worker", map[string]interface{}{
+			"credential_type": "service",
+			"account_id":      accountId,
+			"worker_secrets":  "api-worker:ACCESS_CLIENT_ID:client_id,api-worker:ACCESS_CLIENT_SECRET:client_secret",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Service Role With Worker Secrets", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "worker")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, []string{"api-worker:ACCESS_CLIENT_ID:client_id", "api-worker:ACCESS_CLIENT_SECRET:client_secret"}, resp.Data["worker_secrets"])
+	})
+
+	t.Run("Reject Invalid Worker Secret", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "worker-invalid", map[string]interface{}{
+			"credential_type": "service",
+			"account_id":      accountId,
+			"worker_secrets":  "api-worker",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Worker Secrets On Other Types", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "worker-dns", map[string]interface{}{
+			"credential_type":      "dns-record",
+			"zone_id":              "testzoneid",
+			"allowed_record_types": "TXT",
+			"allowed_record_names": "*.example.com",
+			"worker_secrets":       "api-worker:TOKEN",
+		})
+
+		require.Error(t, err)
+	})
+}
+
+func TestAPITokenRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	policies := `[{"effect":"allow","resources":{"com.cloudflare.api.account.zone.*":"*"},"permission_groups":[{"id":"c8fed203ed3043cba015a93ad1616f1f"}]}]`
+
+	t.Run("Create Account Owned API Token Role", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "zone-read", map[string]interface{}{
+			"credential_type": "api",
+			"account_id":      accountId,
+			"policies":        policies,
+			"token_owner":     "account",
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Account Owned API Token Role", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "zone-read")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, policies, resp.Data["policies"])
+		require.Equal(t, "account", resp.Data["token_owner"])
+	})
+
+	t.Run("Default To User Owned Tokens", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "zone-read-user", map[string]interface{}{
+			"credential_type": "api",
+			"policies":        policies,
+		})
+		require.Nil(t, err)
+
+		resp, err := testServiceRoleRead(t, b, s, "zone-read-user")
+		require.Nil(t, err)
+		require.Equal(t, "user", resp.Data["token_owner"])
+	})
+
+	t.Run("Reject Account Owner Without Account", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "zone-read-invalid", map[string]interface{}{
+			"credential_type": "api",
+			"policies":        policies,
+			"token_owner":     "account",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Invalid Policies", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "zone-read-invalid", map[string]interface{}{
+			"credential_type": "api",
+			"policies":        "[]",
+		})
+
+		require.Error(t, err)
+	})
+}
+
+func TestServiceRoleMaxBatch(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Create Service Role With Max Batch", func(t *testing.T) {
+		resp, err := testServiceRoleCreate(t, b, s, "load-test", map[string]interface{}{
+			"credential_type": "service",
+			"account_id":      accountId,
+			"max_batch":       50,
+		})
+
+		require.Nil(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Read Service Role With Max Batch", func(t *testing.T) {
+		resp, err := testServiceRoleRead(t, b, s, "load-test")
+
+		require.Nil(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, 50, resp.Data["max_batch"])
+	})
+
+	t.Run("Reject Count Above Max Batch", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "service-token/load-test",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"count": 51,
+			},
+		})
+
+		require.Nil(t, err)
+		require.True(t, resp.IsError())
+	})
+
+	t.Run("Reject Invalid Max Batch", func(t *testing.T) {
+		_, err := testServiceRoleCreate(t, b, s, "load-test-invalid", map[string]interface{}{
+			"credential_type": "service",
+			"account_id":      accountId,
+			"max_batch":       1000,
+		})
+
+		require.Error(t, err)
+	})
+}
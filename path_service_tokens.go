@@ -4,11 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+// batchConcurrency bounds the concurrent Cloudflare requests made while issuing a batch of service tokens.
+const batchConcurrency = 5
+
+// maxServiceTokenBatch is the largest max_batch a role can allow.
+const maxServiceTokenBatch = 100
+
 func pathServiceTokens(b *cloudflareBackend) *framework.Path {
 	return &framework.Path{
 		Pattern: "service-token/" + framework.GenericNameRegex("name"),
@@ -18,13 +26,26 @@ func pathServiceTokens(b *cloudflareBackend) *framework.Path {
 				Description: "Name of the role",
 				Required:    true,
 			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "Lease TTL of the generated credential. It cannot exceed the role's max_ttl",
+			},
+			"name_suffix": {
+				Type:        framework.TypeString,
+				Description: "Suffix appended to the generated credential name, such as a pipeline identifier",
+			},
+			"count": {
+				Type:        framework.TypeInt,
+				Description: "Number of service tokens to generate. More than one requires a role with max_batch, and the tokens share a single lease",
+				Default:     1,
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.ReadOperation: &framework.PathOperation{
-				Callback: b.pathServiceTokensRead,
+				Callback: b.instrumentIssue(credentialTypeService, b.pathServiceTokensRead),
 			},
 			logical.UpdateOperation: &framework.PathOperation{
-				Callback: b.pathServiceTokensRead,
+				Callback: b.instrumentIssue(credentialTypeService, b.pathServiceTokensRead),
 			},
 		},
 		HelpSynopsis:    pathCredentialsHelpSyn,
@@ -44,27 +65,189 @@ func (b *cloudflareBackend) pathServiceTokensRead(ctx context.Context, req *logi
 		return nil, errors.New("error retrieving role: role is nil")
 	}
 
-	return b.createUserCreds(ctx, req, roleName, roleEntry)
+	if roleEntry.CredentialType != credentialTypeService {
+		return logical.ErrorResponse("role %q does not issue service tokens", roleName), nil
+	}
+
+	ttl, err := callerTTL(time.Duration(d.Get("ttl").(int))*time.Second, roleEntry.MaxTTL, b.System())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	nameSuffix := d.Get("name_suffix").(string)
+	if err := validateNameSuffix(nameSuffix); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	count := d.Get("count").(int)
+	if count < 1 {
+		return logical.ErrorResponse("count must be at least 1"), nil
+	}
+
+	if count > 1 {
+		if count > roleEntry.MaxBatch {
+			return logical.ErrorResponse("count %d exceeds the max_batch of role %q", count, roleName), nil
+		}
+
+		if len(roleEntry.WorkerSecrets) > 0 {
+			return logical.ErrorResponse("role %q syncs worker_secrets and cannot issue batches", roleName), nil
+		}
+	}
+
+	if resp, err := b.checkIssuanceRate(ctx, req, roleName, roleEntry, count); resp != nil || err != nil {
+		return resp, err
+	}
+
+	activeId, err := b.reserveActiveCredentials(ctx, req.Storage, roleName, roleEntry.MaxActive, count, ttl)
+	if errors.Is(err, errMaxActive) {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *logical.Response
+	if count > 1 {
+		resp, err = b.createBatchCreds(ctx, req, roleName, roleEntry, count, ttl, nameSuffix)
+	} else {
+		resp, err = b.createUserCreds(ctx, req, roleName, roleEntry, ttl, nameSuffix)
+	}
+
+	if err != nil {
+		if releaseErr := b.releaseActiveCredentials(ctx, req.Storage, roleName, activeId, count); releaseErr != nil {
+			b.Logger().Error("error releasing max_active reservation", "role", roleName, "error", releaseErr)
+		}
+		return cloudflareErrorResponse(req, err)
+	}
+
+	resp.Secret.InternalData["active_id"] = activeId
+
+	b.recordIssuedCredentials(ctx, req, credentialTypeService, resp.Secret)
+
+	return resp, nil
 }
 
-func (b *cloudflareBackend) createUserCreds(ctx context.Context, req *logical.Request, roleName string, role *cloudflareRoleEntry) (*logical.Response, error) {
-	token, err := b.createToken(ctx, req.Storage, role)
+func (b *cloudflareBackend) createUserCreds(ctx context.Context, req *logical.Request, roleName string, role *cloudflareRoleEntry, ttl time.Duration, nameSuffix string) (*logical.Response, error) {
+	nameTemplate := role.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = defaultServiceTokenNameTemplate
+	}
+
+	name, err := b.credentialName(req, roleName, nameTemplate, nameSuffix)
 	if err != nil {
 		return nil, err
 	}
 
+	token, err := b.createToken(ctx, req.Storage, name, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.syncWorkerSecrets(ctx, req.Storage, role.AccountID, role.WorkerSecrets, token.TokenID, token.toResponseData()); err != nil {
+		b.discardServiceToken(ctx, req.Storage, token.TokenID, roleName, role, "after failed worker secret sync")
+		return nil, err
+	}
+
 	resp := b.Secret(cloudflareServiceTokenType).Response(token.toResponseData(), map[string]interface{}{
-		"token_name":    token.TokenName,
-		"token_id":      token.TokenID,
-		"client_id":     token.ClientID,
-		"client_secret": token.ClientSecret,
-		"role":          roleName,
+		"token_name":     token.TokenName,
+		"token_id":       token.TokenID,
+		"client_id":      token.ClientID,
+		"client_secret":  token.ClientSecret,
+		"account_id":     role.AccountID,
+		"role":           roleName,
+		"worker_secrets": role.WorkerSecrets,
+	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = role.MaxTTL
+
+	return resp, nil
+}
+
+// createBatchCreds creates count service tokens with bounded concurrency under a single lease. If any
+// token fails to be created, the tokens created so far are deleted again.
+func (b *cloudflareBackend) createBatchCreds(ctx context.Context, req *logical.Request, roleName string, role *cloudflareRoleEntry, count int, ttl time.Duration, nameSuffix string) (*logical.Response, error) {
+	nameTemplate := role.NameTemplate
+	if nameTemplate == "" {
+		nameTemplate = defaultServiceTokenNameTemplate
+	}
+
+	names := make([]string, count)
+	for i := range names {
+		name, err := b.credentialName(req, roleName, nameTemplate, nameSuffix)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = name
+	}
+
+	client, err := b.getClient(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	createCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tokens := make([]*cloudflareServiceToken, count)
+	errs := make([]error, count)
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			tokens[i], errs[i] = createToken(createCtx, client, names[i], role)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Report the error that failed the batch rather than the cancellations it caused.
+	var batchErr error
+	for _, err := range errs {
+		if err != nil && (batchErr == nil || errors.Is(batchErr, context.Canceled)) {
+			batchErr = err
+		}
+	}
+
+	if batchErr != nil {
+		for _, token := range tokens {
+			if token == nil {
+				continue
+			}
+			b.discardServiceToken(ctx, req.Storage, token.TokenID, roleName, role, "after failed batch")
+		}
+		return nil, fmt.Errorf("error creating service token batch: %w", batchErr)
+	}
+
+	tokenData := make([]map[string]interface{}, count)
+	tokenIds := make([]string, count)
+	for i, token := range tokens {
+		tokenData[i] = token.toResponseData()
+		tokenIds[i] = token.TokenID
+	}
+
+	resp := b.Secret(cloudflareServiceTokenBatchType).Response(map[string]interface{}{
+		"tokens": tokenData,
+	}, map[string]interface{}{
+		"token_ids":  tokenIds,
+		"account_id": role.AccountID,
+		"role":       roleName,
 	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = role.MaxTTL
+	resp.AddWarning(fmt.Sprintf("the %d service tokens share a single lease: renewing or revoking it renews or revokes every token", count))
 
 	return resp, nil
 }
 
-func (b *cloudflareBackend) createToken(ctx context.Context, s logical.Storage, roleEntry *cloudflareRoleEntry) (*cloudflareServiceToken, error) {
+func (b *cloudflareBackend) createToken(ctx context.Context, s logical.Storage, name string, roleEntry *cloudflareRoleEntry) (*cloudflareServiceToken, error) {
 	client, err := b.getClient(ctx, s)
 	if err != nil {
 		return nil, err
@@ -72,7 +255,7 @@ func (b *cloudflareBackend) createToken(ctx context.Context, s logical.Storage,
 
 	var token *cloudflareServiceToken
 
-	token, err = createToken(ctx, client, roleEntry)
+	token, err = createToken(ctx, client, name, roleEntry)
 	if err != nil {
 		return nil, fmt.Errorf("error creating service token: %w", err)
 	}
@@ -91,4 +274,18 @@ Generate a Cloudflare service token from a specific Vault role.
 const pathCredentialsHelpDesc = `
 This path generates a Cloudflare service token
 based on a particular role.
+
+Setting count generates several independent service tokens in one request, up
+to the role's max_batch. As a Vault response carries a single lease, the tokens
+share one lease and are renewed and revoked together. A batch counts as count
+credentials against the role's and the mount's rate_limit.
+
+Roles with max_idle delete service tokens at Cloudflare once Access has not seen
+them used for max_idle, or never used them within idle_grace_period of issuance.
+Vault keeps the lease until it expires or is revoked, and renewing it fails.
+Worker secrets and max_active reservations are released with the lease.
+
+Audit logs HMAC every response field. The token_id, token_name and client_id
+identifiers can be left in the clear by tuning the mount's
+audit_non_hmac_response_keys.
 `
@@ -2,6 +2,7 @@ package cloudflare_secrets_engine
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
 )
 
 func newAcceptanceTestEnv() (*testEnv, error) {
@@ -52,3 +54,69 @@ func TestAcceptanceServiceToken(t *testing.T) {
 	t.Run("read service token cred", acceptanceTestEnv.ReadServiceToken)
 	t.Run("cleanup user tokens", acceptanceTestEnv.CleanupServiceTokens)
 }
+
+func TestServiceTokenFakeAPI(t *testing.T) {
+	b, s, api := getTestBackendWithFakeAPI(t)
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+	})
+	require.NoError(t, err)
+
+	t.Run("Issue, Renew And Revoke", func(t *testing.T) {
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Data["client_secret"])
+		require.Contains(t, api.serviceTokens, resp.Data["token_id"])
+
+		_, err = testSecretRequest(t, b, s, logical.RenewOperation, resp.Secret)
+		require.NoError(t, err)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.NoError(t, err)
+		require.NotContains(t, api.serviceTokens, resp.Data["token_id"])
+	})
+
+	t.Run("Retry Rate Limited Requests", func(t *testing.T) {
+		api.failNext(2, http.StatusTooManyRequests)
+
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Secret)
+	})
+
+	t.Run("Report Upstream Failures", func(t *testing.T) {
+		// Creates are not retried on server errors, as Cloudflare may have committed them.
+		api.failNext(1, http.StatusServiceUnavailable)
+
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.Data[logical.HTTPStatusCode])
+	})
+
+	t.Run("Revoke Fails On Missing Token", func(t *testing.T) {
+		resp, err := testServiceTokenRead(t, b, s, "ci", nil)
+		require.NoError(t, err)
+
+		api.failNext(1, http.StatusNotFound)
+
+		_, err = testSecretRequest(t, b, s, logical.RevokeOperation, resp.Secret)
+		require.Error(t, err)
+	})
+
+	t.Run("Give Up At The Request Deadline", func(t *testing.T) {
+		api.slowDown(2 * time.Second)
+		defer api.slowDown(0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		_, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "service-token/ci",
+			Storage:   s,
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
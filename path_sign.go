@@ -0,0 +1,137 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const defaultSignedURLTTL = time.Hour
+
+func pathSign(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "sign/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the signing key",
+				Required:    true,
+			},
+			"video_id": {
+				Type:        framework.TypeString,
+				Description: "The Stream video id to sign a token for. Required for stream keys",
+			},
+			"access_rules": {
+				Type:        framework.TypeString,
+				Description: "JSON encoded list of Stream access rules to embed in the token",
+			},
+			"downloadable": {
+				Type:        framework.TypeBool,
+				Description: "Allow the Stream video to be downloaded with the token",
+			},
+			"url": {
+				Type:        framework.TypeString,
+				Description: "The Images delivery url to sign. Required for images keys",
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "How long the signed url is valid for. It cannot exceed the signing key's max_ttl",
+				Default:     int(defaultSignedURLTTL.Seconds()),
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathSignWrite,
+			},
+		},
+		HelpSynopsis:    pathSignHelpSyn,
+		HelpDescription: pathSignHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathSignWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	keyEntry, err := getSigningKey(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyEntry == nil {
+		return logical.ErrorResponse("signing key %q not found", name), nil
+	}
+
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	if ttl <= 0 {
+		return logical.ErrorResponse("ttl must be positive"), nil
+	}
+
+	if maxTTL := keyEntry.maxTTL(); ttl > maxTTL {
+		return logical.ErrorResponse("ttl %s exceeds the maximum of %s", ttl, maxTTL), nil
+	}
+	expiry := time.Now().Add(ttl)
+
+	switch keyEntry.KeyType {
+	case signingKeyTypeStream:
+		videoId := d.Get("video_id").(string)
+		if videoId == "" {
+			return logical.ErrorResponse("missing video_id"), nil
+		}
+
+		claims := streamTokenClaims{
+			Subject:      videoId,
+			Expiry:       expiry.Unix(),
+			Downloadable: d.Get("downloadable").(bool),
+		}
+
+		if accessRules := d.Get("access_rules").(string); accessRules != "" {
+			if err := json.Unmarshal([]byte(accessRules), &claims.AccessRules); err != nil {
+				return logical.ErrorResponse("invalid access_rules: %s", err), nil
+			}
+		}
+
+		token, err := signStreamToken(keyEntry.PrivateKey, keyEntry.KeyID, claims)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"token":      token,
+				"expiration": expiry.Unix(),
+			},
+		}, nil
+	case signingKeyTypeImages:
+		rawURL := d.Get("url").(string)
+		if rawURL == "" {
+			return logical.ErrorResponse("missing url"), nil
+		}
+
+		signedURL, err := signImagesURL(keyEntry.PrivateKey, rawURL, expiry)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"signed_url": signedURL,
+				"expiration": expiry.Unix(),
+			},
+		}, nil
+	default:
+		return logical.ErrorResponse("signing key %q has unsupported key_type %q", name, keyEntry.KeyType), nil
+	}
+}
+
+const pathSignHelpSyn = `
+Sign a Cloudflare Stream or Images url with a stored signing key.
+`
+
+const pathSignHelpDesc = `
+This path mints an RS256 signed url token for a Stream video, or an HMAC signed
+Images delivery url, using a signing key stored in Vault. The signing key itself
+is never returned. The ttl of each url is limited by the signing key's max_ttl.
+`
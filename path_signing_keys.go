@@ -0,0 +1,286 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	signingKeyStoragePrefix = "signing-key/"
+
+	// defaultSigningKeyMaxTTL applies to keys stored without a max_ttl.
+	defaultSigningKeyMaxTTL = 24 * time.Hour
+)
+
+type cloudflareSigningKeyEntry struct {
+	KeyType    string        `json:"type"`
+	AccountID  string        `json:"account_id"`
+	KeyID      string        `json:"key_id"`
+	PrivateKey string        `json:"private_key"`
+	Managed    bool          `json:"managed"`
+	CreatedAt  time.Time     `json:"created_at"`
+	MaxTTL     time.Duration `json:"max_ttl,omitempty"`
+}
+
+// maxTTL returns the longest lifetime of urls and tokens signed with the key.
+func (entry *cloudflareSigningKeyEntry) maxTTL() time.Duration {
+	if entry.MaxTTL > 0 {
+		return entry.MaxTTL
+	}
+	return defaultSigningKeyMaxTTL
+}
+
+func pathSigningKey(b *cloudflareBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "signing-key/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeLowerCaseString,
+					Description: "Name of the signing key",
+					Required:    true,
+				},
+				"key_type": {
+					Type:        framework.TypeString,
+					Description: "The signing key type, either \"stream\" for Stream signed URL tokens or \"images\" for Images signed URLs",
+					Required:    true,
+				},
+				"account_id": {
+					Type:        framework.TypeString,
+					Description: "The cloudflare account id the signing key belongs to",
+					Required:    true,
+				},
+				"key_id": {
+					Type:        framework.TypeString,
+					Description: "The id of an existing Stream key, or the name of an existing Images key, to import. If omitted, a new key is created",
+				},
+				"private_key": {
+					Type:        framework.TypeString,
+					Description: "The PEM encoded private key of an existing Stream key, or the value of an existing Images key, to import",
+					DisplayAttrs: &framework.DisplayAttributes{
+						Sensitive: true,
+					},
+				},
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The longest ttl urls and tokens can be signed for with the key",
+					Default:     int(defaultSigningKeyMaxTTL.Seconds()),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathSigningKeysRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSigningKeysWrite,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.pathSigningKeysDelete,
+				},
+			},
+			HelpSynopsis:    pathSigningKeyHelpSynopsis,
+			HelpDescription: pathSigningKeyHelpDescription,
+			ExistenceCheck:  b.pathSigningKeyExistenceCheck,
+		},
+		{
+			Pattern: "signing-key/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathSigningKeysList,
+				},
+			},
+			HelpSynopsis:    pathSigningKeyListHelpSynopsis,
+			HelpDescription: pathSigningKeyListHelpDescription,
+		},
+	}
+}
+
+func (b *cloudflareBackend) pathSigningKeysList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, signingKeyStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *cloudflareBackend) pathSigningKeysRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entry, err := getSigningKey(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key_type":   entry.KeyType,
+			"account_id": entry.AccountID,
+			"key_id":     entry.KeyID,
+			"managed":    entry.Managed,
+			"created_at": entry.CreatedAt,
+			"max_ttl":    int64(entry.maxTTL().Seconds()),
+		},
+	}, nil
+}
+
+func (b *cloudflareBackend) pathSigningKeysWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	keyType := d.Get("key_type").(string)
+	if keyType != signingKeyTypeStream && keyType != signingKeyTypeImages {
+		return nil, fmt.Errorf("invalid key_type in cloudflare signing key")
+	}
+
+	accountId := d.Get("account_id").(string)
+	if accountId == "" {
+		return nil, fmt.Errorf("missing account_id in cloudflare signing key")
+	}
+
+	entry := &cloudflareSigningKeyEntry{
+		KeyType:    keyType,
+		AccountID:  accountId,
+		KeyID:      d.Get("key_id").(string),
+		PrivateKey: d.Get("private_key").(string),
+		CreatedAt:  time.Now().UTC(),
+		MaxTTL:     time.Duration(d.Get("max_ttl").(int)) * time.Second,
+	}
+
+	if entry.MaxTTL <= 0 {
+		return nil, fmt.Errorf("max_ttl must be positive")
+	}
+
+	if (entry.KeyID == "") != (entry.PrivateKey == "") {
+		return nil, fmt.Errorf("key_id and private_key must be provided together to import a cloudflare signing key")
+	}
+
+	if entry.PrivateKey != "" && keyType == signingKeyTypeStream {
+		if _, err := parseRSAPrivateKey(entry.PrivateKey); err != nil {
+			return nil, fmt.Errorf("invalid private_key in cloudflare signing key: %w", err)
+		}
+	}
+
+	if entry.PrivateKey == "" {
+		client, err := b.getClient(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+
+		switch keyType {
+		case signingKeyTypeStream:
+			entry.KeyID, entry.PrivateKey, err = createStreamSigningKey(ctx, client, accountId)
+		case signingKeyTypeImages:
+			entry.KeyID = "vault-" + name
+			entry.PrivateKey, err = createImagesSigningKey(ctx, client, accountId, entry.KeyID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry.Managed = true
+	}
+
+	if err := setSigningKey(ctx, req.Storage, name, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *cloudflareBackend) pathSigningKeysDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	entry, err := getSigningKey(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	if entry.Managed {
+		client, err := b.getClient(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry.KeyType {
+		case signingKeyTypeStream:
+			err = deleteStreamSigningKey(ctx, client, entry.AccountID, entry.KeyID)
+		case signingKeyTypeImages:
+			err = deleteImagesSigningKey(ctx, client, entry.AccountID, entry.KeyID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error deleting cloudflare signing key: %w", err)
+		}
+	}
+
+	if err := req.Storage.Delete(ctx, signingKeyStoragePrefix+name); err != nil {
+		return nil, fmt.Errorf("error deleting cloudflare signing key: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (b *cloudflareBackend) pathSigningKeyExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	entry, err := getSigningKey(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, fmt.Errorf("error reading cloudflare signing key: %w", err)
+	}
+
+	return entry != nil, nil
+}
+
+func setSigningKey(ctx context.Context, s logical.Storage, name string, keyEntry *cloudflareSigningKeyEntry) error {
+	entry, err := logical.StorageEntryJSON(signingKeyStoragePrefix+name, keyEntry)
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		return fmt.Errorf("failed to create storage entry for cloudflare signing key")
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func getSigningKey(ctx context.Context, s logical.Storage, name string) (*cloudflareSigningKeyEntry, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing signing key name")
+	}
+
+	entry, err := s.Get(ctx, signingKeyStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	var key cloudflareSigningKeyEntry
+
+	if err := entry.DecodeJSON(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+const (
+	pathSigningKeyHelpSynopsis    = `Manages signing keys for Cloudflare Stream and Images signed URLs.`
+	pathSigningKeyHelpDescription = `
+This path creates or imports a Stream or Images signing key. The private key is
+stored seal-wrapped and is never returned; use the sign endpoint to mint signed
+URLs with it. Keys created by Vault are deleted from Cloudflare when deleted here.
+Signing keys cannot be updated, delete and recreate them instead.
+`
+
+	pathSigningKeyListHelpSynopsis    = `List the existing signing keys in the cloudflare backend`
+	pathSigningKeyListHelpDescription = `Signing keys will be listed by name.`
+)
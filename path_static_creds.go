@@ -0,0 +1,66 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathStaticCreds(b *cloudflareBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the static role",
+				Required:    true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathStaticCredsRead,
+			},
+		},
+		HelpSynopsis:    pathStaticCredsHelpSyn,
+		HelpDescription: pathStaticCredsHelpDesc,
+	}
+}
+
+func (b *cloudflareBackend) pathStaticCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	roleEntry, err := getStaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleEntry == nil {
+		return logical.ErrorResponse("static role %q not found", name), nil
+	}
+
+	ttl := roleEntry.RotationPeriod - time.Since(roleEntry.LastRotated)
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"sitekey":             roleEntry.SiteKey,
+			"secret":              roleEntry.Secret,
+			"last_vault_rotation": roleEntry.LastRotated,
+			"rotation_period":     int64(roleEntry.RotationPeriod.Seconds()),
+			"ttl":                 int64(ttl.Seconds()),
+		},
+	}, nil
+}
+
+const pathStaticCredsHelpSyn = `
+Read the current credential of a static role.
+`
+
+const pathStaticCredsHelpDesc = `
+This path returns the current credential managed by a static role, along with
+the time until Vault next rotates it.
+`
@@ -0,0 +1,419 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	staticRoleStoragePrefix = "static-role/"
+
+	staticCredentialTypeTurnstile = "turnstile"
+
+	defaultRotationPeriod = 30 * 24 * time.Hour
+)
+
+type cloudflareStaticRoleEntry struct {
+	CredentialType        string        `json:"type"`
+	AccountID             string        `json:"account_id"`
+	SiteKey               string        `json:"sitekey"`
+	RotationPeriod        time.Duration `json:"rotation_period"`
+	InvalidateImmediately bool          `json:"invalidate_immediately"`
+	Secret                string        `json:"secret"`
+	LastRotated           time.Time     `json:"last_rotated"`
+	WorkerSecrets         []string      `json:"worker_secrets,omitempty"`
+}
+
+func pathStaticRole(b *cloudflareBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "static-role/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeLowerCaseString,
+					Description: "Name of the static role",
+					Required:    true,
+				},
+				"credential_type": {
+					Type:        framework.TypeString,
+					Description: "The static credential type, \"turnstile\" for Turnstile widget secrets",
+					Required:    true,
+				},
+				"account_id": {
+					Type:        framework.TypeString,
+					Description: "The cloudflare account id the credential belongs to",
+				},
+				"sitekey": {
+					Type:        framework.TypeString,
+					Description: "The sitekey of the Turnstile widget whose secret is rotated",
+				},
+				"rotation_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How often the secret is rotated",
+					Default:     int(defaultRotationPeriod.Seconds()),
+				},
+				"invalidate_immediately": {
+					Type:        framework.TypeBool,
+					Description: "Invalidate the previous secret on rotation, instead of allowing the grace period Cloudflare provides",
+				},
+				"worker_secrets": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Worker secret bindings, as script:BINDING or script:BINDING:field, that the credential is written to on every rotation. Without a field the whole credential is written as JSON. Bindings removed from the role are deleted",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathStaticRolesRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathStaticRolesWrite,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathStaticRolesWrite,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.pathStaticRolesDelete,
+				},
+			},
+			HelpSynopsis:    pathStaticRoleHelpSynopsis,
+			HelpDescription: pathStaticRoleHelpDescription,
+			ExistenceCheck:  b.pathStaticRoleExistenceCheck,
+		},
+		{
+			Pattern: "static-role/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathStaticRolesList,
+				},
+			},
+			HelpSynopsis:    pathStaticRoleListHelpSynopsis,
+			HelpDescription: pathStaticRoleListHelpDescription,
+		},
+		{
+			Pattern: "rotate-role/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeLowerCaseString,
+					Description: "Name of the static role",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathRotateRole,
+				},
+			},
+			HelpSynopsis:    pathRotateRoleHelpSynopsis,
+			HelpDescription: pathRotateRoleHelpDescription,
+		},
+	}
+}
+
+func (b *cloudflareBackend) pathStaticRolesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, staticRoleStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *cloudflareBackend) pathStaticRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entry, err := getStaticRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"credential_type":        entry.CredentialType,
+			"account_id":             entry.AccountID,
+			"sitekey":                entry.SiteKey,
+			"rotation_period":        int64(entry.RotationPeriod.Seconds()),
+			"invalidate_immediately": entry.InvalidateImmediately,
+			"last_vault_rotation":    entry.LastRotated,
+			"worker_secrets":         entry.WorkerSecrets,
+		},
+	}, nil
+}
+
+func (b *cloudflareBackend) pathStaticRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleLock.Lock()
+	defer b.staticRoleLock.Unlock()
+
+	roleEntry, err := getStaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleEntry == nil {
+		roleEntry = &cloudflareStaticRoleEntry{}
+	}
+
+	createOperation := req.Operation == logical.CreateOperation
+	rotate := createOperation || roleEntry.Secret == ""
+	syncWorkerSecrets := false
+	previousAccountId, previousWorkerSecrets := roleEntry.AccountID, roleEntry.WorkerSecrets
+
+	if credentialType, ok := d.GetOk("credential_type"); ok {
+		if credentialType != staticCredentialTypeTurnstile {
+			return nil, fmt.Errorf("invalid credential_type in cloudflare static role")
+		}
+		roleEntry.CredentialType = credentialType.(string)
+	} else if createOperation {
+		return nil, fmt.Errorf("missing credential_type in cloudflare static role")
+	}
+
+	if accountId, ok := d.GetOk("account_id"); ok {
+		rotate = rotate || accountId.(string) != roleEntry.AccountID
+		roleEntry.AccountID = accountId.(string)
+	}
+
+	if siteKey, ok := d.GetOk("sitekey"); ok {
+		rotate = rotate || siteKey.(string) != roleEntry.SiteKey
+		roleEntry.SiteKey = siteKey.(string)
+	}
+
+	if rotationPeriod, ok := d.GetOk("rotation_period"); ok {
+		roleEntry.RotationPeriod = time.Duration(rotationPeriod.(int)) * time.Second
+	} else if createOperation {
+		roleEntry.RotationPeriod = time.Duration(d.Get("rotation_period").(int)) * time.Second
+	}
+
+	if invalidateImmediately, ok := d.GetOk("invalidate_immediately"); ok {
+		roleEntry.InvalidateImmediately = invalidateImmediately.(bool)
+	}
+
+	if workerSecrets, ok := d.GetOk("worker_secrets"); ok {
+		for _, target := range workerSecrets.([]string) {
+			if _, err := parseWorkerSecretTarget(target); err != nil {
+				return nil, fmt.Errorf("invalid worker_secrets in cloudflare static role: %w", err)
+			}
+		}
+		syncWorkerSecrets = !strutil.EquivalentSlices(workerSecrets.([]string), roleEntry.WorkerSecrets)
+		roleEntry.WorkerSecrets = workerSecrets.([]string)
+	}
+
+	if roleEntry.AccountID == "" {
+		return nil, fmt.Errorf("missing account_id in cloudflare static role")
+	}
+
+	if roleEntry.SiteKey == "" {
+		return nil, fmt.Errorf("missing sitekey in cloudflare static role")
+	}
+
+	if roleEntry.RotationPeriod < time.Minute {
+		return nil, fmt.Errorf("rotation_period must be at least one minute")
+	}
+
+	if rotate {
+		err = b.rotateStaticRole(ctx, req.Storage, name, roleEntry)
+	} else {
+		err = setStaticRole(ctx, req.Storage, name, roleEntry)
+		if err == nil && syncWorkerSecrets {
+			err = b.syncStaticRoleWorkerSecrets(ctx, req.Storage, name, roleEntry)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Worker secrets the role no longer writes to would otherwise keep serving its last credential.
+	removed := removedWorkerSecrets(previousAccountId, previousWorkerSecrets, roleEntry.AccountID, roleEntry.WorkerSecrets)
+	if err := b.releaseWorkerSecrets(ctx, req.Storage, previousAccountId, removed, staticRoleStoragePrefix+name); err != nil {
+		return nil, fmt.Errorf("error releasing removed worker_secrets: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (b *cloudflareBackend) pathStaticRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleLock.Lock()
+	defer b.staticRoleLock.Unlock()
+
+	roleEntry, err := getStaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleEntry != nil {
+		if err := b.releaseWorkerSecrets(ctx, req.Storage, roleEntry.AccountID, roleEntry.WorkerSecrets, staticRoleStoragePrefix+name); err != nil {
+			return nil, fmt.Errorf("error deleting cloudflare static role: %w", err)
+		}
+	}
+
+	err = req.Storage.Delete(ctx, staticRoleStoragePrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting cloudflare static role: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (b *cloudflareBackend) pathStaticRoleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	entry, err := getStaticRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, fmt.Errorf("error reading cloudflare static role: %w", err)
+	}
+
+	return entry != nil, nil
+}
+
+func (b *cloudflareBackend) pathRotateRole(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleLock.Lock()
+	defer b.staticRoleLock.Unlock()
+
+	roleEntry, err := getStaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleEntry == nil {
+		return logical.ErrorResponse("static role %q not found", name), nil
+	}
+
+	return nil, b.rotateStaticRole(ctx, req.Storage, name, roleEntry)
+}
+
+// rotateStaticRole rotates the credential of a static role and stores the new value.
+// Callers must hold staticRoleLock.
+func (b *cloudflareBackend) rotateStaticRole(ctx context.Context, s logical.Storage, name string, roleEntry *cloudflareStaticRoleEntry) error {
+	client, err := b.getClient(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	secret, err := rotateTurnstileSecret(ctx, client, roleEntry.AccountID, roleEntry.SiteKey, roleEntry.InvalidateImmediately)
+	if err != nil {
+		return err
+	}
+
+	roleEntry.Secret = secret
+	roleEntry.LastRotated = time.Now().UTC()
+
+	if err := setStaticRole(ctx, s, name, roleEntry); err != nil {
+		return err
+	}
+
+	b.sendEvent(ctx, eventStaticRoleRotate, map[string]interface{}{
+		"role":       name,
+		"account_id": roleEntry.AccountID,
+		"sitekey":    roleEntry.SiteKey,
+	})
+
+	return b.syncStaticRoleWorkerSecrets(ctx, s, name, roleEntry)
+}
+
+// syncStaticRoleWorkerSecrets writes the current credential of a static role into its Worker secrets.
+func (b *cloudflareBackend) syncStaticRoleWorkerSecrets(ctx context.Context, s logical.Storage, name string, roleEntry *cloudflareStaticRoleEntry) error {
+	return b.syncWorkerSecrets(ctx, s, roleEntry.AccountID, roleEntry.WorkerSecrets, staticRoleStoragePrefix+name, map[string]interface{}{
+		"sitekey": roleEntry.SiteKey,
+		"secret":  roleEntry.Secret,
+	})
+}
+
+// rotateExpiredStaticRoles rotates every static role whose rotation period has elapsed.
+func (b *cloudflareBackend) rotateExpiredStaticRoles(ctx context.Context, req *logical.Request) error {
+	replicationState := b.System().ReplicationState()
+	if replicationState.HasState(consts.ReplicationPerformanceSecondary) || replicationState.HasState(consts.ReplicationPerformanceStandby) {
+		return nil
+	}
+
+	names, err := req.Storage.List(ctx, staticRoleStoragePrefix)
+	if err != nil {
+		return err
+	}
+
+	b.staticRoleLock.Lock()
+	defer b.staticRoleLock.Unlock()
+
+	for _, name := range names {
+		roleEntry, err := getStaticRole(ctx, req.Storage, name)
+		if err != nil {
+			return err
+		}
+
+		if roleEntry == nil || time.Since(roleEntry.LastRotated) < roleEntry.RotationPeriod {
+			continue
+		}
+
+		if err := b.rotateStaticRole(ctx, req.Storage, name, roleEntry); err != nil {
+			b.Logger().Error("error rotating static role", "role", name, "error", err)
+			continue
+		}
+
+		b.Logger().Info("rotated static role", "role", name)
+	}
+
+	return nil
+}
+
+func setStaticRole(ctx context.Context, s logical.Storage, name string, roleEntry *cloudflareStaticRoleEntry) error {
+	entry, err := logical.StorageEntryJSON(staticRoleStoragePrefix+name, roleEntry)
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		return fmt.Errorf("failed to create storage entry for cloudflare static role")
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func getStaticRole(ctx context.Context, s logical.Storage, name string) (*cloudflareStaticRoleEntry, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing static role name")
+	}
+
+	entry, err := s.Get(ctx, staticRoleStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role cloudflareStaticRoleEntry
+
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+const (
+	pathStaticRoleHelpSynopsis    = `Manages the Vault static roles for rotating cloudflare credentials.`
+	pathStaticRoleHelpDescription = `
+This path allows you to read and write static roles. A static role references an
+existing Cloudflare credential, such as a Turnstile widget secret, which Vault
+rotates on creation and then every rotation period. Each rotated value can also
+be written into Worker secret bindings listed in worker_secrets.
+`
+
+	pathStaticRoleListHelpSynopsis    = `List the existing static roles in the cloudflare backend`
+	pathStaticRoleListHelpDescription = `Static roles will be listed by the role name.`
+
+	pathRotateRoleHelpSynopsis    = `Rotate the credential of a static role.`
+	pathRotateRoleHelpDescription = `
+This path rotates the credential of a static role immediately, independent of
+its rotation period.
+`
+)
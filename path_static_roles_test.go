@@ -0,0 +1,125 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticRole(t *testing.T) {
+	b, s := getTestBackend(t)
+
+	t.Run("Reject Invalid Credential Type", func(t *testing.T) {
+		_, err := testStaticRoleCreate(t, b, s, "widget", map[string]interface{}{
+			"credential_type": "service",
+			"account_id":      accountId,
+			"sitekey":         "testsitekey",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Missing Sitekey", func(t *testing.T) {
+		_, err := testStaticRoleCreate(t, b, s, "widget", map[string]interface{}{
+			"credential_type": "turnstile",
+			"account_id":      accountId,
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Reject Short Rotation Period", func(t *testing.T) {
+		_, err := testStaticRoleCreate(t, b, s, "widget", map[string]interface{}{
+			"credential_type": "turnstile",
+			"account_id":      accountId,
+			"sitekey":         "testsitekey",
+			"rotation_period": "30s",
+		})
+
+		require.Error(t, err)
+	})
+
+	t.Run("Read Missing Static Creds", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "static-creds/widget",
+			Storage:   s,
+		})
+
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+	})
+}
+
+func TestStaticRoleWorkerSecrets(t *testing.T) {
+	client := newFakeClient()
+	b, s := getTestBackendWithClient(t, client)
+
+	_, err := testStaticRoleCreate(t, b, s, "widget", map[string]interface{}{
+		"credential_type": "turnstile",
+		"account_id":      accountId,
+		"sitekey":         "testsitekey",
+		"worker_secrets":  "signup:TURNSTILE_SECRET:secret,login:TURNSTILE_SECRET:secret",
+	})
+	require.NoError(t, err)
+	require.Len(t, client.workerSecrets, 2)
+
+	ownerKey := workerSecretStoragePrefix + accountId + "/login/TURNSTILE_SECRET"
+	entry, err := s.Get(context.Background(), ownerKey)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+
+	t.Run("Release Removed Targets", func(t *testing.T) {
+		_, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "static-role/widget",
+			Data:      map[string]interface{}{"worker_secrets": "signup:TURNSTILE_SECRET:secret"},
+			Storage:   s,
+		})
+		require.NoError(t, err)
+		require.Contains(t, client.workerSecrets, "signup/TURNSTILE_SECRET")
+		require.NotContains(t, client.workerSecrets, "login/TURNSTILE_SECRET")
+
+		entry, err := s.Get(context.Background(), ownerKey)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+
+	t.Run("Keep Targets Owned By Another Role", func(t *testing.T) {
+		_, err := testStaticRoleCreate(t, b, s, "other-widget", map[string]interface{}{
+			"credential_type": "turnstile",
+			"account_id":      accountId,
+			"sitekey":         "othersitekey",
+			"worker_secrets":  "signup:TURNSTILE_SECRET:secret",
+		})
+		require.NoError(t, err)
+
+		_, err = b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "static-role/widget",
+			Data:      map[string]interface{}{"worker_secrets": "login:TURNSTILE_SECRET:secret"},
+			Storage:   s,
+		})
+		require.NoError(t, err)
+		require.Contains(t, client.workerSecrets, "signup/TURNSTILE_SECRET")
+		require.Contains(t, client.workerSecrets, "login/TURNSTILE_SECRET")
+	})
+}
+
+func TestRemovedWorkerSecrets(t *testing.T) {
+	require.Equal(t, []string{"login:TOKEN"}, removedWorkerSecrets("a", []string{"signup:TOKEN", "login:TOKEN"}, "a", []string{"signup:TOKEN:secret"}))
+	require.Equal(t, []string{"signup:TOKEN"}, removedWorkerSecrets("a", []string{"signup:TOKEN"}, "b", []string{"signup:TOKEN"}))
+	require.Empty(t, removedWorkerSecrets("a", nil, "a", []string{"signup:TOKEN"}))
+}
+
+func testStaticRoleCreate(t *testing.T, b *cloudflareBackend, s logical.Storage, name string, d map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "static-role/" + name,
+		Data:      d,
+		Storage:   s,
+	})
+}
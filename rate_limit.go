@@ -0,0 +1,101 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/time/rate"
+)
+
+const defaultRateLimitInterval = time.Minute
+
+// issuanceLimiter is a token bucket limiting credential issuance, along with the settings it was built
+// from so it can be rebuilt when they change.
+type issuanceLimiter struct {
+	limiter  *rate.Limiter
+	limit    int
+	interval time.Duration
+	burst    int
+}
+
+// issuanceLimiterFor returns the limiter stored under key, rebuilding it if its settings changed.
+// Callers must hold rateLimitLock.
+func (b *cloudflareBackend) issuanceLimiterFor(key string, limit int, interval time.Duration, burst int) *issuanceLimiter {
+	if interval <= 0 {
+		interval = defaultRateLimitInterval
+	}
+
+	if burst <= 0 {
+		burst = limit
+	}
+
+	l, ok := b.issuanceLimiters[key]
+	if !ok || l.limit != limit || l.interval != interval || l.burst != burst {
+		l = &issuanceLimiter{
+			limiter:  rate.NewLimiter(rate.Every(interval/time.Duration(limit)), burst),
+			limit:    limit,
+			interval: interval,
+			burst:    burst,
+		}
+		b.issuanceLimiters[key] = l
+	}
+
+	return l
+}
+
+// forgetIssuanceLimiter drops the limiter of a role that was updated or deleted. An updated role
+// starts over with a full bucket, built from its new settings on its next issuance.
+func (b *cloudflareBackend) forgetIssuanceLimiter(roleName string) {
+	b.rateLimitLock.Lock()
+	defer b.rateLimitLock.Unlock()
+
+	delete(b.issuanceLimiters, "role/"+roleName)
+}
+
+// checkIssuanceRate consumes count credentials from the role's and the mount's rate limits, so a
+// batch counts as many credentials as it issues. If either limit cannot cover the whole request,
+// it returns a 429 response and consumes from neither.
+func (b *cloudflareBackend) checkIssuanceRate(ctx context.Context, req *logical.Request, roleName string, role *cloudflareRoleEntry, count int) (*logical.Response, error) {
+	config, err := getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	b.rateLimitLock.Lock()
+	defer b.rateLimitLock.Unlock()
+
+	now := time.Now()
+	var reservations []*rate.Reservation
+	var limited string
+
+	if role.RateLimit > 0 {
+		l := b.issuanceLimiterFor("role/"+roleName, role.RateLimit, role.RateLimitInterval, role.RateLimitBurst)
+		r := l.limiter.ReserveN(now, count)
+		reservations = append(reservations, r)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			limited = fmt.Sprintf("role %q is limited to %d credentials per %s", roleName, l.limit, l.interval)
+		}
+	}
+
+	if limited == "" && config != nil && config.RateLimit > 0 {
+		l := b.issuanceLimiterFor("config", config.RateLimit, config.RateLimitInterval, config.RateLimitBurst)
+		r := l.limiter.ReserveN(now, count)
+		reservations = append(reservations, r)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			limited = fmt.Sprintf("mount is limited to %d credentials per %s", l.limit, l.interval)
+		}
+	}
+
+	if limited == "" {
+		return nil, nil
+	}
+
+	for _, r := range reservations {
+		r.CancelAt(now)
+	}
+
+	return logical.RespondWithStatusCode(logical.ErrorResponse("rate limit exceeded: %s, try again later", limited), req, http.StatusTooManyRequests)
+}
@@ -0,0 +1,157 @@
+package cloudflare_secrets_engine
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIssuanceRate(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+	req := &logical.Request{Storage: s}
+
+	role := &cloudflareRoleEntry{RateLimit: 2, RateLimitInterval: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		resp, err := b.checkIssuanceRate(ctx, req, "ci", role, 1)
+		require.NoError(t, err)
+		require.Nil(t, resp)
+	}
+
+	resp, err := b.checkIssuanceRate(ctx, req, "ci", role, 1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+
+	resp, err = b.checkIssuanceRate(ctx, req, "other", &cloudflareRoleEntry{}, 1)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestCheckIssuanceRateMount(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+	req := &logical.Request{Storage: s}
+
+	entry, err := logical.StorageEntryJSON(configStoragePath, &cloudflareConfig{
+		RateLimit:         1,
+		RateLimitInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Put(ctx, entry))
+
+	role := &cloudflareRoleEntry{RateLimit: 2, RateLimitInterval: time.Hour}
+
+	resp, err := b.checkIssuanceRate(ctx, req, "ci", role, 1)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.checkIssuanceRate(ctx, req, "ci", role, 1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+
+	resp, err = b.checkIssuanceRate(ctx, req, "other", &cloudflareRoleEntry{}, 1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+
+	// The mount limit rejected the request, so it must not have consumed the role's limit either.
+	entry, err = logical.StorageEntryJSON(configStoragePath, &cloudflareConfig{})
+	require.NoError(t, err)
+	require.NoError(t, s.Put(ctx, entry))
+
+	resp, err = b.checkIssuanceRate(ctx, req, "ci", role, 1)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestCheckIssuanceRateBatch(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+	req := &logical.Request{Storage: s}
+
+	role := &cloudflareRoleEntry{RateLimit: 5, RateLimitInterval: time.Hour}
+
+	resp, err := b.checkIssuanceRate(ctx, req, "ci", role, 6)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+
+	resp, err = b.checkIssuanceRate(ctx, req, "ci", role, 4)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = b.checkIssuanceRate(ctx, req, "ci", role, 2)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+
+	// The rejected batch consumed nothing, so a single credential still fits.
+	resp, err = b.checkIssuanceRate(ctx, req, "ci", role, 1)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+func TestIssuanceRateInvalidRequests(t *testing.T) {
+	b, s := getTestBackendWithClient(t, newFakeClient())
+
+	_, err := testServiceRoleCreate(t, b, s, "origin", map[string]interface{}{
+		"credential_type":   "origin-ca",
+		"allowed_hostnames": "*.example.com",
+		"rate_limit":        1,
+	})
+	require.NoError(t, err)
+
+	// Requests rejected by validation do not consume the limit.
+	for i := 0; i < 2; i++ {
+		resp, err := testOriginCAWrite(t, b, s, "origin", map[string]interface{}{"hostnames": "www.example.org"})
+		require.NoError(t, err)
+		require.True(t, resp.IsError())
+		require.NotEqual(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+	}
+
+	resp, err := testOriginCAWrite(t, b, s, "origin", map[string]interface{}{"hostnames": "www.example.com"})
+	require.NoError(t, err)
+	require.False(t, resp.IsError(), resp.Error())
+
+	resp, err = testOriginCAWrite(t, b, s, "origin", map[string]interface{}{"hostnames": "www.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.Data[logical.HTTPStatusCode])
+}
+
+func TestForgetIssuanceLimiter(t *testing.T) {
+	b, s := getTestBackend(t)
+	ctx := context.Background()
+
+	_, err := testServiceRoleCreate(t, b, s, "ci", map[string]interface{}{
+		"credential_type": "service",
+		"account_id":      accountId,
+		"rate_limit":      1,
+	})
+	require.NoError(t, err)
+
+	role, err := b.getRole(ctx, s, "ci")
+	require.NoError(t, err)
+
+	consume := func() {
+		resp, err := b.checkIssuanceRate(ctx, &logical.Request{Storage: s}, "ci", role, 1)
+		require.NoError(t, err)
+		require.Nil(t, resp)
+		require.Contains(t, b.issuanceLimiters, "role/ci")
+	}
+
+	consume()
+	_, err = testServiceRoleUpdate(t, b, s, "ci", map[string]interface{}{"rate_limit": 0})
+	require.NoError(t, err)
+	require.NotContains(t, b.issuanceLimiters, "role/ci")
+
+	consume()
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "role/ci",
+		Storage:   s,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, b.issuanceLimiters, "role/ci")
+}